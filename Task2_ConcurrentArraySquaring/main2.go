@@ -1,30 +1,112 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
-	"sync"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kras0Tanya/WB-L1/Task2_ConcurrentArraySquaring/parallel"
+	"github.com/Kras0Tanya/WB-L1/internal/checked"
+)
+
+// режимы обработки переполнения при возведении в квадрат (-overflow)
+const (
+	overflowError    = "error"    // ошибка для этого числа, остальные считаются дальше
+	overflowSaturate = "saturate" // результат ограничивается math.MaxInt64
+	overflowBig      = "big"      // квадрат этого числа считается в math/big
 )
 
+// squareResult - квадрат одного числа: Value, а если он не поместился в int64 -
+// Big (режим big) или Err (режим error); Saturated - значение обрезано до границы int64
+type squareResult struct {
+	Value     int64
+	Big       *big.Int
+	Saturated bool
+	Err       error
+}
+
+// checkedSquare возвращает функцию возведения в квадрат с проверкой переполнения в режиме mode
+func checkedSquare(mode string) func(n int64) squareResult {
+	return func(n int64) squareResult {
+		sq, err := checked.Mul(n, n)
+		if err == nil {
+			return squareResult{Value: sq}
+		}
+		switch mode {
+		case overflowSaturate:
+			return squareResult{Value: checked.SatMul(n, n), Saturated: true}
+		case overflowBig: // повышаем до math/big только это число, остальные остаются int64
+			b := big.NewInt(n)
+			return squareResult{Big: b.Mul(b, b)}
+		default:
+			return squareResult{Err: fmt.Errorf("%d * %d не помещается в int64: %w", n, n, err)}
+		}
+	}
+}
+
+// parseNumbers разбирает список чисел через запятую
+func parseNumbers(s string) ([]int64, error) {
+	var numbers []int64
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q не является числом int64", field)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
 func main() {
-	// Создаем слайс чисел и waitGroup
-	numbers := []int{2, 4, 6, 8, 10}
-
-	var wg sync.WaitGroup
-
-	// Используем WaitGroup для ожидания завершения группы задач, через wg.Add увеличиваем внутренний счетчик wg
-	wg.Add(len(numbers))
-
-	// в цикле перебираем последовательно все числа и запускаем горутины (одна горутина рассчитывает квадрат переданного числа и выводит в Stdout)
-	for _, n := range numbers {
-		go func(n int) {
-			// через wg.Done мы уменьшаем счетчик на один (defer указывает что вызов функции произойдет перед завершением горутины),
-			defer wg.Done()
-			square := n * n
-			fmt.Printf("%d * %d = %d\n", n, n, square)
-		}(n)
+	// без флагов - исходная задача; сравнение parallel.Map с горутиной на элемент - в бенчмарках пакета parallel:
+	// go test -bench . ./parallel (для 10 млн чисел: go test -bench . ./parallel -args -bench-size=10000000)
+	workers := flag.Int("workers", 0, "сколько горутин использует parallel.Map (0 - по числу процессоров)")
+	numbersFlag := flag.String("numbers", "2,4,6,8,10", "числа через запятую, например 3037000500,-9223372036854775808")
+	overflow := flag.String("overflow", overflowError, "что делать, если квадрат не помещается в int64: error, saturate или big")
+	flag.Parse()
+
+	switch *overflow {
+	case overflowError, overflowSaturate, overflowBig:
+	default:
+		fmt.Printf("Ошибка: неизвестный режим -overflow %q (допустимо: error, saturate, big)\n", *overflow)
+		os.Exit(1)
+	}
+
+	// Создаем слайс чисел (по умолчанию [2,4,6,8,10] из условия)
+	numbers, err := parseNumbers(*numbersFlag)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
+
+	// квадраты считаются конкурентно, но parallel.Map возвращает их в порядке входа,
+	// поэтому вывод всегда одинаковый (в исходном решении горутины печатали в случайном порядке)
+	squares, err := parallel.Map(context.Background(), numbers, checkedSquare(*overflow), *workers)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
+	failed := false
+	for i, n := range numbers {
+		switch sq := squares[i]; {
+		case sq.Err != nil: // ошибка относится только к этому числу - остальные всё равно выводим
+			fmt.Println("Ошибка:", sq.Err)
+			failed = true
+		case sq.Big != nil:
+			fmt.Printf("%d * %d = %s (math/big)\n", n, n, sq.Big)
+		case sq.Saturated:
+			fmt.Printf("%d * %d = %d (переполнение, ограничено максимумом int64)\n", n, n, sq.Value)
+		default:
+			fmt.Printf("%d * %d = %d\n", n, n, sq.Value)
+		}
+	}
+	if failed {
+		os.Exit(1)
 	}
-	// wg.Wait блокирует main и ждет когда в счетчике будет 0
-	wg.Wait()
 	fmt.Println("Done!")
 }
 
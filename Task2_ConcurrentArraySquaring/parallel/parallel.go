@@ -0,0 +1,85 @@
+// Package parallel - параллельный map над слайсом, выросший из решения L1.2
+// (там на каждый элемент запускалась своя горутина, а результаты печатались в случайном порядке).
+// здесь число горутин ограничено, элементы раздаются пачками, а результаты лежат в порядке входа
+package parallel
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minChunk - наименьший размер пачки на больших входах: на меньших пачках раздача работы обходится дороже самой работы.
+// вход меньше workers*minChunk делится поровну между воркерами, чтобы и короткий вход обрабатывался параллельно
+const minChunk = 256
+
+// chunksPerWorker - на сколько пачек в среднем делится работа одного воркера. пачек больше, чем воркеров,
+// чтобы освободившийся воркер мог взять чужую работу, если элементы обрабатываются неравномерно
+const chunksPerWorker = 4
+
+// Map применяет f к каждому элементу in и возвращает результаты в порядке входа.
+// работают не больше workers горутин (workers <= 0 - runtime.GOMAXPROCS(0)); вход делится на пачки,
+// и воркеры по очереди забирают следующую пачку через атомарный счётчик, поэтому ни каналов, ни блокировок
+// на каждый элемент нет: каждый воркер пишет только в свои индексы результата.
+// отмена ctx проверяется между пачками; в этом случае возвращаются nil и ctx.Err()
+func Map[T, R any](ctx context.Context, in []T, f func(T) R, workers int) ([]R, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	out := make([]R, len(in))
+	if len(in) == 0 {
+		return out, ctx.Err()
+	}
+
+	chunk := max(len(in)/(workers*chunksPerWorker), min(minChunk, (len(in)+workers-1)/workers))
+	chunks := (len(in) + chunk - 1) / chunk
+	workers = min(workers, chunks) // лишние воркеры остались бы без работы
+
+	var (
+		next atomic.Int64 // номер следующей свободной пачки
+		wg   sync.WaitGroup
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				c := int(next.Add(1) - 1)
+				if c >= chunks {
+					return
+				}
+				lo, hi := c*chunk, min((c+1)*chunk, len(in))
+				for i := lo; i < hi; i++ {
+					out[i] = f(in[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PerItem - исходный подход L1.2 для сравнения: своя горутина на каждый элемент.
+// порядок результатов тоже сохраняется (горутина пишет в свой индекс), но на больших входах
+// планирование миллионов горутин и их стеки обходятся дороже самих вычислений
+func PerItem[T, R any](ctx context.Context, in []T, f func(T) R) ([]R, error) {
+	out := make([]R, len(in))
+	var wg sync.WaitGroup
+	wg.Add(len(in))
+	for i, v := range in {
+		go func() {
+			defer wg.Done()
+			out[i] = f(v)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
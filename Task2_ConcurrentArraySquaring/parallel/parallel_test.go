@@ -0,0 +1,160 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func square(n int) int {
+	return n * n
+}
+
+func numbers(n int) []int {
+	in := make([]int, n)
+	for i := range in {
+		in[i] = i + 1
+	}
+	return in
+}
+
+// результаты лежат в порядке входа при любом числе воркеров и размере входа (в том числе меньше одной пачки)
+func TestMapOrder(t *testing.T) {
+	for _, n := range []int{0, 1, minChunk - 1, minChunk + 1, 10_000} {
+		for _, workers := range []int{0, 1, 3, 16} {
+			out, err := Map(context.Background(), numbers(n), square, workers)
+			if err != nil {
+				t.Fatalf("n=%d workers=%d: %v", n, workers, err)
+			}
+			if len(out) != n {
+				t.Fatalf("n=%d workers=%d: получено %d результатов", n, workers, len(out))
+			}
+			for i, v := range out {
+				if v != (i+1)*(i+1) {
+					t.Fatalf("n=%d workers=%d: out[%d] = %d, ожидалось %d", n, workers, i, v, (i+1)*(i+1))
+				}
+			}
+		}
+	}
+}
+
+// одновременно f выполняют не больше workers горутин
+func TestMapWorkersBound(t *testing.T) {
+	const workers = 3
+	var running, peak atomic.Int64
+	f := func(v int) int {
+		cur := running.Add(1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		if v%minChunk == 0 { // изредка уступаем процессор, чтобы воркеры действительно пересекались
+			time.Sleep(time.Millisecond)
+		}
+		running.Add(-1)
+		return v
+	}
+	if _, err := Map(context.Background(), numbers(100*minChunk), f, workers); err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > workers {
+		t.Fatalf("одновременно работало %d горутин, ожидалось не больше %d", p, workers)
+	}
+}
+
+// короткий вход (меньше одной пачки minChunk) всё равно раздаётся всем воркерам: все 5 вызовов f
+// одновременно ждут друг друга, и Map завершается, только если они действительно идут параллельно
+func TestMapSmallInputFansOut(t *testing.T) {
+	const n = 5
+	var running, timedOut atomic.Int64
+	all := make(chan struct{})
+	f := func(v int) int {
+		if running.Add(1) == n {
+			close(all)
+		}
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+			timedOut.Add(1)
+		}
+		return v * v
+	}
+	out, err := Map(context.Background(), numbers(n), f, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := timedOut.Load(); c > 0 {
+		t.Fatalf("5 чисел при 5 воркерах обработаны не параллельно: %d вызовов не дождались остальных", c)
+	}
+	if out[n-1] != n*n {
+		t.Fatalf("out = %v", out)
+	}
+}
+
+// после отмены ctx воркеры не берут новые пачки, а Map возвращает nil и ctx.Err()
+func TestMapCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := numbers(100_000)
+	var calls atomic.Int64
+	out, err := Map(ctx, in, func(v int) int {
+		if calls.Add(1) == 1 {
+			cancel()
+		}
+		return v
+	}, 2)
+	if !errors.Is(err, context.Canceled) || out != nil {
+		t.Fatalf("получено %d результатов и ошибка %v, ожидались nil и context.Canceled", len(out), err)
+	}
+	if c := calls.Load(); c >= int64(len(in)) {
+		t.Fatalf("после отмены обработаны все %d элементов", c)
+	}
+
+	// пустой вход с уже отменённым контекстом тоже возвращает ошибку
+	if _, err := Map(ctx, []int{}, square, 2); !errors.Is(err, context.Canceled) {
+		t.Fatalf("пустой вход: ошибка %v, ожидалась context.Canceled", err)
+	}
+}
+
+// сравнение подходов: go test -bench . -benchmem ./parallel
+// размер входа задаётся флагом, например для 10 млн чисел: go test -bench . -benchmem ./parallel -args -bench-size=10000000
+// (горутина на элемент на таком входе требует несколько ГБ, поэтому по умолчанию вход умеренный)
+var benchSize = flag.Int("bench-size", 1_000_000, "размер входа в бенчмарках")
+
+func BenchmarkSequential(b *testing.B) {
+	in := numbers(*benchSize)
+	out := make([]int, len(in))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, v := range in {
+			out[j] = square(v)
+		}
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	in := numbers(*benchSize)
+	b.ReportMetric(float64(runtime.GOMAXPROCS(0)), "workers")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Map(context.Background(), in, square, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPerItem(b *testing.B) {
+	in := numbers(*benchSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := PerItem(context.Background(), in, square); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/Kras0Tanya/WB-L1/Task3_WorkerPoolImplementation/ratelimit"
+	"github.com/Kras0Tanya/WB-L1/Task3_WorkerPoolImplementation/workerpool"
+	el "github.com/Kras0Tanya/WB-L1/internal/eventlog"
+)
+
+// process - обработчик задачи: сам воркер и канал живут в пакете workerpool,
+// в этом примере обработка сводится к выводу числа, поэтому число возвращается как есть
+func process(_ context.Context, data int) (int, error) {
+	return data, nil
+}
+
+// controlRate меняет лимит на лету по командам из stdin, по одной в строке:
+// "rate 50/s", "rate 0" (без ограничения) или "burst 10" - набираются в терминале или подаются через pipe
+func controlRate(limiter *ratelimit.TokenBucket, logger *slog.Logger) {
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		if err := limiter.Apply(sc.Text()); err != nil {
+			logger.Error("ошибка изменения лимита", el.KeyEvent, el.EventRateChange, "error", err)
+			continue
+		}
+		logger.Info("лимит изменён", el.KeyEvent, el.EventRateChange, "rate", limiter.Rate(), "burst", limiter.Burst())
+	}
+}
+
+func main() {
+	// флаги задаются до количества воркеров: go run ./cmd --rate=100/s --burst=20 5
+	rateStr := flag.String("rate", "", "ограничение скорости выдачи чисел воркерам, например 100/s или 6000/m (по умолчанию без ограничения; на лету - строка \"rate 50/s\" в stdin)")
+	burst := flag.Int("burst", 1, "сколько чисел можно выдать подряд без ожидания (ёмкость token bucket)")
+	metricsAddr := flag.String("metrics-addr", "", "адрес HTTP-сервера с метриками Prometheus, например 127.0.0.1:9090 (по умолчанию выключен)")
+	format := flag.String("format", "text", el.FormatUsage)
+	ordered := flag.Int("ordered", 0, "выводить результаты в порядке отправки чисел, с буфером перестановки указанного размера (0 - по мере готовности)")
+	flag.Parse()
+
+	// все события (запуск воркера, обработка числа, остановка) пишутся структурированными записями в stdout
+	logger, err := el.New(os.Stdout, *format)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
+
+	// проверяем, передан ли аргумент (есть ли аргументы кроме флагов), иначе - завершаем с кодом ошибки os.Exit(1)
+	if flag.NArg() < 1 {
+		fmt.Println("Ошибка: укажите количество воркеров (например, go run ./cmd 5)")
+		os.Exit(1)
+	}
+
+	// получаем аргумент (количество воркеров): flag.Arg(0) - строка, содержащая первый аргумент после флагов (напр., "5")
+	// strconv.Atoi преобразует строку в int, если строка не является числом, возвращается ошибка
+	// если err != nil - аргумент некорректен (напр., "ayz"), программа завершается с ошибкой
+	numWorkersStr := flag.Arg(0)
+	numWorkers, err := strconv.Atoi(numWorkersStr)
+	if err != nil {
+		fmt.Printf("Ошибка: %s не является числом\n", numWorkersStr)
+		os.Exit(1)
+	}
+
+	// проверяем, что количество воркеров больше 0
+	if numWorkers <= 0 {
+		fmt.Println("Ошибка: количество воркеров должно быть больше 0")
+		os.Exit(1)
+	}
+
+	opts := []workerpool.Option{workerpool.WithLogger(logger)}
+	if *ordered > 0 {
+		opts = append(opts, workerpool.WithOrderedResults(*ordered))
+	}
+	// лимитер применяется к выдаче задач воркерам; без --rate скорость не ограничена (rate = 0),
+	// но её можно задать на лету командой из stdin (см. controlRate)
+	var rate float64
+	if *rateStr != "" {
+		if rate, err = ratelimit.ParseRate(*rateStr); err != nil {
+			fmt.Println("Ошибка:", err)
+			os.Exit(1)
+		}
+	}
+	limiter := ratelimit.New(rate, *burst)
+	opts = append(opts, workerpool.WithRateLimit(limiter))
+
+	// создаем пул: внутри запускается numWorkers горутин-воркеров, которые читают общий канал задач
+	pool, err := workerpool.New(numWorkers, process, opts...)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
+
+	// метрики пула в формате Prometheus: curl http://127.0.0.1:9090/metrics
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", pool.MetricsHandler("task3"))
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				logger.Error("ошибка HTTP-сервера метрик", "error", err)
+			}
+		}()
+	}
+
+	// буф.канал для сигналов завершения и сами сигналы
+	// программа завершается при нажатии Ctrl+C (сигнал SIGINT) или другом сигнале типа kill (SIGTERM).
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	// SIGUSR1 добавляет воркера, SIGUSR2 убирает одного (но не меньше одного воркера);
+	// удалённый воркер дообрабатывает текущее число и выходит, pool.Wait() дождётся и его
+	// пример: kill -USR1 <pid>
+	resizeCh := make(chan os.Signal, 1)
+	signal.Notify(resizeCh, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range resizeCh {
+			size := pool.Size()
+			if sig == syscall.SIGUSR1 {
+				size++
+			} else {
+				size--
+			}
+			if err := pool.Resize(size); err != nil {
+				logger.Error("ошибка изменения количества воркеров", el.KeyEvent, el.EventResize, "error", err)
+				continue
+			}
+			logger.Info("количество воркеров изменено", el.KeyEvent, el.EventResize, "workers", pool.Size())
+		}
+	}()
+
+	go controlRate(limiter, logger)
+
+	// горутина для постоянной записи данных в пул
+	go func() {
+		for i := 1; ; i++ {
+			select {
+			case sig := <-sigCh: // если получен сигнал (Ctrl+C)
+				logger.Info("получен сигнал, закрываем пул", el.KeyEvent, el.EventShutdown, "signal", sig.String())
+				pool.Close() // закрываем пул (внутри закрывается канал задач)
+				return
+			default:
+				if err := pool.Submit(i); err != nil { // записываем данные
+					return
+				}
+			}
+		}
+	}()
+
+	// выводим результаты в stdout; цикл завершится, когда все воркеры закончат работу
+	for res := range pool.Results() {
+		if res.Err != nil {
+			logger.Error("воркер не смог обработать число", el.KeyEvent, el.EventItemFailed,
+				"seq", res.ID, el.KeyWorker, res.WorkerID, el.KeyItem, res.Item, el.KeyDuration, res.Duration, "error", res.Err)
+			continue
+		}
+		logger.Info("воркер обработал число", el.KeyEvent, el.EventItemProcessed,
+			"seq", res.ID, el.KeyWorker, res.WorkerID, el.KeyItem, res.Value, el.KeyDuration, res.Duration)
+	}
+
+	// ждем завершения воркеров
+	pool.Wait()
+	stats := pool.Stats()
+	logger.Info("все воркеры завершили работу", el.KeyEvent, el.EventSummary,
+		"processed", stats.Processed, "failed", stats.Failed, "panics", stats.Panics, "mean_duration", stats.Latency.Mean())
+}
+
+/*
+Работа нескольких воркеров
+
+Реализовать постоянную запись данных в канал (в главной горутине).
+Реализовать набор из N воркеров, которые читают данные из этого канала и выводят их в stdout.
+Программа должна принимать параметром количество воркеров и при старте создавать указанное число горутин-воркеров.
+*/
@@ -0,0 +1,208 @@
+// Package ratelimit - ограничитель скорости по алгоритму token bucket.
+// в "ведро" ёмкостью burst токены добавляются со скоростью rate в секунду;
+// каждое действие забирает один токен, а если токенов нет - ждёт, пока он накопится.
+// используется пулом воркеров L1.3 (выдача задач) и продюсером L1.4; пакет публичный,
+// потому что *TokenBucket передаётся в workerpool.WithRateLimit и из других модулей
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenBucket - потокобезопасный token bucket; скорость и ёмкость можно менять на лету
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // токенов в секунду; 0 - без ограничения
+	burst  int     // ёмкость ведра
+	tokens float64 // текущее количество токенов (может быть отрицательным - это "долг" уже выданных резервов)
+	last   time.Time
+
+	changed chan struct{} // закрывается и пересоздаётся при каждом SetRate и SetBurst (см. Changed)
+}
+
+// New создаёт ведро со скоростью rate токенов в секунду и ёмкостью burst (минимум 1).
+// ведро изначально заполнено, поэтому первые burst действий проходят без ожидания
+func New(rate float64, burst int) *TokenBucket {
+	burst = max(burst, 1)
+	return &TokenBucket{
+		rate:    max(rate, 0),
+		burst:   burst,
+		tokens:  float64(burst),
+		last:    time.Now(),
+		changed: make(chan struct{}),
+	}
+}
+
+// refill добавляет токены, накопившиеся с прошлого вызова; вызывается под mu
+func (b *TokenBucket) refill(now time.Time) {
+	if b.rate > 0 {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+	}
+	b.tokens = min(b.tokens, float64(b.burst))
+	b.last = now
+}
+
+// Reserve забирает один токен и возвращает, сколько нужно подождать перед действием (0 - можно сразу).
+// токен резервируется в любом случае, поэтому после Reserve действие нужно выполнить
+func (b *TokenBucket) Reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate == 0 {
+		return 0
+	}
+	b.refill(time.Now())
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Allow забирает токен, только если он есть прямо сейчас
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate == 0 {
+		return true
+	}
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Cancel возвращает в ведро токен, зарезервированный Reserve, если действие так и не выполнено
+// (например, ожидание прервано изменением скорости или отменой)
+func (b *TokenBucket) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens = min(b.tokens+1, float64(b.burst))
+}
+
+// Changed возвращает канал, который закроется при следующем изменении скорости или ёмкости.
+// ожидание резерва выбирает по нему вместе с таймером: после изменения старый резерв отменяется (Cancel)
+// и ожидание пересчитывается новым Reserve - иначе снижение лимита с 1/m до 1000/s вступило бы в силу
+// только через минуту. канал нужно получить до Reserve, чтобы не пропустить изменение между ними
+func (b *TokenBucket) Changed() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.changed
+}
+
+// Wait блокируется, пока не появится токен, или до отмены контекста.
+// изменение скорости или ёмкости во время ожидания сразу пересчитывает его
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	for {
+		changed := b.Changed()
+		d := b.Reserve()
+		if d <= 0 {
+			return ctx.Err()
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-timer.C:
+			return nil
+		case <-changed:
+			timer.Stop()
+			b.Cancel()
+		case <-ctx.Done():
+			timer.Stop()
+			b.Cancel()
+			return ctx.Err()
+		}
+	}
+}
+
+// SetRate меняет скорость (токенов в секунду); 0 отключает ограничение
+func (b *TokenBucket) SetRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now()) // токены, накопленные по старой скорости, сохраняются
+	b.rate = max(rate, 0)
+	b.notify()
+}
+
+// SetBurst меняет ёмкость ведра (минимум 1)
+func (b *TokenBucket) SetBurst(burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.burst = max(burst, 1)
+	b.tokens = min(b.tokens, float64(b.burst))
+	b.notify()
+}
+
+// notify будит всех, кто ждёт резерв по Changed; вызывается под mu
+func (b *TokenBucket) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Rate возвращает текущую скорость в токенах в секунду
+func (b *TokenBucket) Rate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+// Burst возвращает текущую ёмкость ведра
+func (b *TokenBucket) Burst() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.burst
+}
+
+// ParseRate разбирает скорость в виде "100/s", "6000/m", "5/100ms" или просто "100" (в секунду)
+// и возвращает количество действий в секунду
+func ParseRate(s string) (float64, error) {
+	countStr, per, found := strings.Cut(strings.TrimSpace(s), "/")
+	count, err := strconv.ParseFloat(countStr, 64)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("ratelimit: некорректная скорость %q", s)
+	}
+	if !found {
+		return count, nil
+	}
+
+	// единица без числа ("s", "m", "h") означает один такой интервал
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+	interval, err := time.ParseDuration(per)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("ratelimit: некорректный интервал в скорости %q", s)
+	}
+	return count / interval.Seconds(), nil
+}
+
+// Apply выполняет команду управления ведром: "rate 100/s" меняет скорость (в формате ParseRate, 0 - без ограничения),
+// "burst 20" - ёмкость. так программы меняют лимит на лету по строкам из stdin
+func (b *TokenBucket) Apply(cmd string) error {
+	name, arg, _ := strings.Cut(strings.TrimSpace(cmd), " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "rate":
+		rate, err := ParseRate(arg)
+		if err != nil {
+			return err
+		}
+		b.SetRate(rate)
+	case "burst":
+		burst, err := strconv.Atoi(arg)
+		if err != nil || burst <= 0 {
+			return fmt.Errorf("ratelimit: некорректная ёмкость %q", arg)
+		}
+		b.SetBurst(burst)
+	default:
+		return fmt.Errorf("ratelimit: неизвестная команда %q (допустимо: rate 100/s, burst 20)", cmd)
+	}
+	return nil
+}
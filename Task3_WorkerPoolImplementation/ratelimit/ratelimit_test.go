@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRate(t *testing.T) {
+	cases := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"100", 100, true},
+		{"100/s", 100, true},
+		{"6000/m", 100, true},
+		{"5/100ms", 50, true},
+		{"0", 0, true},
+		{"-1/s", 0, false},
+		{"abc", 0, false},
+		{"10/0s", 0, false},
+	}
+	for _, c := range cases {
+		got, err := ParseRate(c.in)
+		if (err == nil) != c.ok || (c.ok && got != c.want) {
+			t.Errorf("ParseRate(%q) = %v, %v; ожидалось %v (ошибка: %v)", c.in, got, err, c.want, !c.ok)
+		}
+	}
+}
+
+// команды Apply меняют параметры уже работающего ведра
+func TestApply(t *testing.T) {
+	b := New(1, 1)
+	b.Reserve() // ведро пустое: следующее действие ждёт ~1с
+	if err := b.Apply("rate 1000/s"); err != nil {
+		t.Fatal(err)
+	}
+	if d := b.Reserve(); d > 10*time.Millisecond {
+		t.Fatalf("после rate 1000/s ожидание %v", d)
+	}
+	if err := b.Apply("burst 20"); err != nil || b.Burst() != 20 {
+		t.Fatalf("burst 20: ёмкость %d, ошибка %v", b.Burst(), err)
+	}
+	if err := b.Apply("rate 0"); err != nil || b.Rate() != 0 || b.Reserve() != 0 {
+		t.Fatalf("rate 0 должен отключать ограничение (ошибка %v)", err)
+	}
+	for _, bad := range []string{"burst 0", "rate x", "speed 5"} {
+		if err := b.Apply(bad); err == nil {
+			t.Errorf("Apply(%q): ожидалась ошибка", bad)
+		}
+	}
+}
+
+// повышение скорости во время ожидания сразу пересчитывает его: Wait по старой скорости 1/m ждал бы минуту
+func TestWaitWakesOnRateChange(t *testing.T) {
+	b := New(1.0/60, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := b.Wait(ctx); err != nil { // ведро изначально полное - первый токен сразу
+		t.Fatal(err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, func() { b.SetRate(1000) })
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("ожидание %d не закончилось после SetRate(1000): %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("10 токенов после SetRate(1000) заняли %v", elapsed)
+	}
+
+	// отменённые резервы возвращаются: после изменения ёмкости ожидающий тоже просыпается и не теряет токен
+	b = New(1.0/60, 1)
+	b.Reserve()
+	time.AfterFunc(50*time.Millisecond, func() { b.Apply("rate 100/s") })
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if b.Allow() {
+		t.Fatal("в ведре остался лишний токен: отменённый резерв вернули дважды")
+	}
+}
+
+// отмена контекста прерывает ожидание и возвращает зарезервированный токен
+func TestWaitCancel(t *testing.T) {
+	b := New(1, 1)
+	b.Reserve()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err == nil {
+		t.Fatal("Wait вернулся без ошибки после отмены контекста")
+	}
+	if d := b.Reserve(); d > 1100*time.Millisecond {
+		t.Fatalf("после отменённого ожидания следующий резерв ждёт %v - токен не возвращён", d)
+	}
+}
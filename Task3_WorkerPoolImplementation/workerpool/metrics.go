@@ -0,0 +1,160 @@
+package workerpool
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets - верхние границы корзин гистограммы времени обработки задачи по умолчанию
+var DefaultLatencyBuckets = []time.Duration{
+	100 * time.Microsecond, time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond,
+	50 * time.Millisecond, 100 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second,
+}
+
+// Bucket - одна корзина гистограммы: сколько задач обработано не дольше UpperBound (накопительно, как в Prometheus)
+type Bucket struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// Histogram - снимок гистограммы времени обработки задач
+type Histogram struct {
+	Buckets []Bucket      // корзины по возрастанию UpperBound; задачи дольше последней границы учтены только в Count
+	Count   uint64        // всего обработанных задач
+	Sum     time.Duration // суммарное время обработки
+}
+
+// Mean возвращает среднее время обработки задачи
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Stats - снимок состояния пула
+type Stats struct {
+	Workers    int            // текущее количество воркеров
+	Busy       int            // воркеры, которые сейчас обрабатывают задачу
+	Idle       int            // воркеры, ожидающие задачу
+	QueueDepth int            // задачи, ожидающие в очереди пула
+	Processed  uint64         // всего обработано задач (успешно и с ошибкой)
+	Failed     uint64         // задачи, завершившиеся ошибкой после всех попыток
+	Panics     uint64         // паники в обработчиках
+	PerWorker  map[int]uint64 // обработано задач по ID воркера (включая уже остановленных)
+	Latency    Histogram      // время обработки задачи (все попытки вместе)
+}
+
+// metrics - счётчики пула; обновляются воркерами и диспетчером
+type metrics struct {
+	busy       atomic.Int64
+	queueDepth atomic.Int64
+	failed     atomic.Uint64
+
+	mu        sync.Mutex // защищает поля ниже
+	perWorker map[int]uint64
+	bounds    []time.Duration
+	buckets   []uint64 // buckets[i] - задачи с временем в (bounds[i-1], bounds[i]], без накопления
+	count     uint64
+	sum       time.Duration
+}
+
+func newMetrics(bounds []time.Duration) *metrics {
+	bounds = slices.Clone(bounds)
+	slices.Sort(bounds)
+	return &metrics{
+		perWorker: make(map[int]uint64),
+		bounds:    bounds,
+		buckets:   make([]uint64, len(bounds)),
+	}
+}
+
+// observe учитывает одну обработанную задачу
+func (m *metrics) observe(workerID int, d time.Duration, failed bool) {
+	if failed {
+		m.failed.Add(1)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.perWorker[workerID]++
+	m.count++
+	m.sum += d
+	if i, _ := slices.BinarySearch(m.bounds, d); i < len(m.bounds) {
+		m.buckets[i]++
+	}
+}
+
+// Stats возвращает снимок метрик пула
+func (p *Pool[T, R]) Stats() Stats {
+	m := p.metrics
+	st := Stats{
+		Workers:    p.Size(),
+		Busy:       int(m.busy.Load()),
+		QueueDepth: int(m.queueDepth.Load()),
+		Failed:     m.failed.Load(),
+		Panics:     p.panics.Load(),
+	}
+	// занятыми могут быть и воркеры, уже удалённые через Resize, поэтому Idle не уходит в минус
+	st.Idle = max(st.Workers-st.Busy, 0)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st.Processed = m.count
+	st.PerWorker = make(map[int]uint64, len(m.perWorker))
+	for id, n := range m.perWorker {
+		st.PerWorker[id] = n
+	}
+	st.Latency = Histogram{Buckets: make([]Bucket, len(m.bounds)), Count: m.count, Sum: m.sum}
+	var cumulative uint64
+	for i, bound := range m.bounds {
+		cumulative += m.buckets[i]
+		st.Latency.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+	}
+	return st
+}
+
+// MetricsHandler возвращает http.Handler, отдающий метрики пула в текстовом формате Prometheus.
+// name добавляется к метрикам меткой pool="name", чтобы различать несколько пулов в одном процессе
+func (p *Pool[T, R]) MetricsHandler(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		st := p.Stats()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		label := fmt.Sprintf("pool=%q", name)
+		gauge := func(metric, help string, v int) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s{%s} %d\n", metric, help, metric, metric, label, v)
+		}
+		counter := func(metric, help string, v uint64) {
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s{%s} %d\n", metric, help, metric, metric, label, v)
+		}
+
+		gauge("workerpool_workers", "Current number of workers.", st.Workers)
+		gauge("workerpool_busy_workers", "Workers currently processing a job.", st.Busy)
+		gauge("workerpool_idle_workers", "Workers waiting for a job.", st.Idle)
+		gauge("workerpool_queue_depth", "Jobs waiting in the pool queue.", st.QueueDepth)
+		counter("workerpool_failed_total", "Jobs that failed after all attempts.", st.Failed)
+		counter("workerpool_panics_total", "Handler panics.", st.Panics)
+
+		fmt.Fprint(w, "# HELP workerpool_processed_total Jobs processed per worker.\n# TYPE workerpool_processed_total counter\n")
+		ids := make([]int, 0, len(st.PerWorker))
+		for id := range st.PerWorker {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			fmt.Fprintf(w, "workerpool_processed_total{%s,worker=\"%d\"} %d\n", label, id, st.PerWorker[id])
+		}
+
+		fmt.Fprint(w, "# HELP workerpool_job_duration_seconds Job processing time.\n# TYPE workerpool_job_duration_seconds histogram\n")
+		for _, b := range st.Latency.Buckets {
+			fmt.Fprintf(w, "workerpool_job_duration_seconds_bucket{%s,le=\"%g\"} %d\n", label, b.UpperBound.Seconds(), b.Count)
+		}
+		fmt.Fprintf(w, "workerpool_job_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", label, st.Latency.Count)
+		fmt.Fprintf(w, "workerpool_job_duration_seconds_sum{%s} %g\n", label, st.Latency.Sum.Seconds())
+		fmt.Fprintf(w, "workerpool_job_duration_seconds_count{%s} %d\n", label, st.Latency.Count)
+	})
+}
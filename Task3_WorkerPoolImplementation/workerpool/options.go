@@ -0,0 +1,117 @@
+package workerpool
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// config - настройки пула, которые задаются опциями в New
+type config struct {
+	ctx            context.Context
+	retry          RetryPolicy
+	deadLetter     bool
+	deadLetterSize int
+	queueSize      int
+	aging          time.Duration
+	limiter        Limiter
+	latencyBuckets []time.Duration
+	logger         *slog.Logger
+	window         int
+}
+
+// Option настраивает пул при создании
+type Option func(*config)
+
+// WithContext задаёт родительский контекст: он передаётся в обработчики,
+// а его отмена прерывает ожидание между повторными попытками
+func WithContext(ctx context.Context) Option {
+	return func(c *config) {
+		if ctx != nil {
+			c.ctx = ctx
+		}
+	}
+}
+
+// WithRetry включает повторные попытки для задач, завершившихся ошибкой
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+	}
+}
+
+// WithDeadLetter включает dead-letter канал с буфером size: в него попадают задачи,
+// которые так и не удалось обработать. канал нужно вычитывать, иначе воркеры заблокируются
+func WithDeadLetter(size int) Option {
+	return func(c *config) {
+		c.deadLetter = true
+		c.deadLetterSize = max(size, 0)
+	}
+}
+
+// WithQueueSize задаёт, сколько задач может ждать в очереди пула; когда очередь заполнена, Submit блокируется
+func WithQueueSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.queueSize = size
+		}
+	}
+}
+
+// WithAging включает защиту от голодания: каждые d ожидания в очереди повышают приоритет задачи на 1.
+// например, при d = 100ms задача PriorityLow через 2с ожидания обгонит только что добавленную PriorityHigh
+func WithAging(d time.Duration) Option {
+	return func(c *config) {
+		c.aging = d
+	}
+}
+
+// Limiter ограничивает скорость выдачи задач воркерам; Reserve забирает разрешение
+// и возвращает, сколько нужно подождать перед выдачей (например, *ratelimit.TokenBucket)
+type Limiter interface {
+	Reserve() time.Duration
+}
+
+// AdjustableLimiter - лимитер, параметры которого меняются на ходу (*ratelimit.TokenBucket).
+// Changed закрывается при изменении, и диспетчер, ждущий разрешения, отменяет резерв через Cancel
+// и запрашивает его заново - иначе повышение скорости подействовало бы только после старого ожидания
+type AdjustableLimiter interface {
+	Limiter
+	Changed() <-chan struct{}
+	Cancel()
+}
+
+// WithRateLimit ограничивает скорость, с которой диспетчер отдаёт задачи воркерам.
+// параметры лимитера можно менять во время работы пула (например, TokenBucket.SetRate):
+// если лимитер реализует AdjustableLimiter, текущее ожидание пересчитывается сразу
+func WithRateLimit(l Limiter) Option {
+	return func(c *config) {
+		c.limiter = l
+	}
+}
+
+// WithLatencyBuckets задаёт границы корзин гистограммы времени обработки (по умолчанию DefaultLatencyBuckets)
+func WithLatencyBuckets(bounds ...time.Duration) Option {
+	return func(c *config) {
+		if len(bounds) > 0 {
+			c.latencyBuckets = bounds
+		}
+	}
+}
+
+// WithLogger включает журнал событий жизненного цикла воркеров (запуск, остановка, паника);
+// записи содержат поля event (worker_start, worker_stop, worker_panic) и worker
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// WithOrderedResults включает выдачу результатов в порядке отправки задач (по возрастанию Result.ID).
+// window ограничивает число результатов, ожидающих в буфере перестановки (минимум 1).
+// в этом режиме задачи выдаются воркерам строго по очереди, приоритеты и WithAging не учитываются
+func WithOrderedResults(window int) Option {
+	return func(c *config) {
+		c.window = max(window, 1)
+	}
+}
@@ -0,0 +1,35 @@
+package workerpool
+
+// упорядоченная выдача результатов (WithOrderedResults).
+// воркеры завершают задачи в произвольном порядке, поэтому их результаты сначала попадают в буфер перестановки,
+// а в Results() уходят строго по возрастанию ID (в порядке вызовов Submit).
+// размер буфера ограничен окном window: диспетчер не выдаёт воркерам задачу, пока число выданных,
+// но ещё не отправленных в Results() задач равно window. так буфер не растёт без предела, а медленный
+// потребитель Results() через окно тормозит диспетчера, а за ним и Submit (backpressure)
+
+// reorder - горутина перестановки: читает результаты воркеров из p.out и отдаёт их в p.results по порядку ID
+func (p *Pool[T, R]) reorder() {
+	defer close(p.reorderDone)
+	defer close(p.results)
+
+	pending := make(map[uint64]Result[T, R], p.window)
+	next := uint64(1) // ID следующего результата, который ждёт потребитель
+	for res := range p.out {
+		pending[res.ID] = res
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			p.results <- r
+			delete(pending, next)
+			next++
+
+			// возвращаем диспетчеру одно место в окне; если диспетчер уже завершился, возвращать некому
+			select {
+			case p.release <- struct{}{}:
+			case <-p.dispatchDone:
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError - паника в обработчике, превращённая в обычную ошибку результата.
+// такие задачи не повторяются: они сразу попадают в Result.Err и dead-letter канал
+type PanicError struct {
+	ItemID uint64 // ID задачи, на которой произошла паника
+	Value  any    // значение, переданное в panic
+	Stack  []byte // стек горутины-воркера в момент паники
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("workerpool: паника при обработке задачи %d: %v", e.ItemID, e.Value)
+}
+
+// safeCall вызывает обработчик и перехватывает панику, чтобы она не уронила весь процесс
+func (p *Pool[T, R]) safeCall(ctx context.Context, j job[T]) (value R, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{ItemID: j.id, Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return p.handler(ctx, j.item)
+}
+
+// replace запускает воркера на замену упавшему: новый воркер получает новый ID,
+// но тот же канал остановки, поэтому Resize по-прежнему управляет этим "слотом" пула.
+// wg.Add вызывается до wg.Done упавшего воркера, так что Wait не завершится раньше времени
+func (p *Pool[T, R]) replace(stop <-chan struct{}) {
+	p.sizeMu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.sizeMu.Unlock()
+
+	p.wg.Add(1)
+	go p.worker(id, stop)
+}
+
+// Panics возвращает, сколько раз обработчики паниковали за время жизни пула
+func (p *Pool[T, R]) Panics() uint64 {
+	return p.panics.Load()
+}
@@ -0,0 +1,319 @@
+// Package workerpool - переиспользуемый пул воркеров, выделенный из решения L1.3.
+// воркеры читают задачи из общего канала, обрабатывают их пользовательским обработчиком
+// и отправляют результаты в канал Results()
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrClosed возвращается при попытке отправить задачу в закрытый пул
+var ErrClosed = errors.New("workerpool: пул закрыт")
+
+// Handler обрабатывает одну задачу; ctx отменяется вместе с контекстом пула (см. WithContext)
+type Handler[T, R any] func(ctx context.Context, item T) (R, error)
+
+// job - задача во внутренней очереди пула
+type job[T any] struct {
+	id    uint64  // порядковый номер задачи (1, 2, ...) в порядке приёма в очередь, назначается диспетчером
+	score float64 // ключ сортировки в очереди (приоритет с учётом старения)
+	item  T
+}
+
+// Result - результат обработки одной задачи
+type Result[T, R any] struct {
+	ID       uint64        // порядковый номер задачи (1, 2, ...) в порядке вызовов Submit
+	WorkerID int           // ID воркера, обработавшего задачу
+	Item     T             // исходная задача
+	Value    R             // значение, которое вернул обработчик (при ошибке - значение последней попытки)
+	Err      error         // ошибка последней попытки; nil, если задача обработана успешно
+	Attempts int           // сколько попыток понадобилось
+	Duration time.Duration // время обработки (все попытки вместе)
+}
+
+// DeadLetter - задача, которую не удалось обработать за все попытки
+type DeadLetter[T any] struct {
+	ID       uint64
+	WorkerID int
+	Item     T
+	Err      error
+	Attempts int
+}
+
+// Pool - пул воркеров, размер которого можно менять во время работы через Resize
+type Pool[T, R any] struct {
+	handler Handler[T, R]
+	retry   RetryPolicy
+	submit  chan job[T]       // Submit -> диспетчер
+	in      chan job[T]       // диспетчер -> воркеры
+	out     chan Result[T, R] // воркеры -> потребитель (или -> горутина перестановки, если включён WithOrderedResults)
+	results chan Result[T, R]
+	dead    chan DeadLetter[T] // nil, если dead-letter канал не включён
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	queueSize int
+	aging     time.Duration
+	limiter   Limiter   // nil - без ограничения скорости
+	start     time.Time // точка отсчёта для старения задач
+
+	mu     sync.RWMutex // защищает closed и отправку в submit (Submit не должен писать в закрытый канал)
+	closed bool
+
+	panics  atomic.Uint64 // сколько раз паниковали обработчики
+	metrics *metrics
+	logger  *slog.Logger // nil - журнал событий выключен
+
+	window       int           // окно упорядоченной выдачи; 0 - результаты выдаются по мере готовности
+	release      chan struct{} // горутина перестановки -> диспетчер: освободилось место в окне
+	dispatchDone chan struct{} // закрывается при выходе диспетчера
+	reorderDone  chan struct{} // закрывается при выходе горутины перестановки
+
+	sizeMu sync.Mutex      // защищает stops и nextID
+	stops  []chan struct{} // каналы остановки активных воркеров, по одному на воркера
+	nextID int             // ID для следующего запускаемого воркера
+
+	wg   sync.WaitGroup
+	done chan struct{} // закрывается, когда все воркеры завершили работу
+}
+
+// New создаёт пул и сразу запускает workers горутин-воркеров.
+// результаты нужно вычитывать из Results(), иначе воркеры заблокируются на отправке
+func New[T, R any](workers int, handler Handler[T, R], opts ...Option) (*Pool[T, R], error) {
+	if workers <= 0 {
+		return nil, errors.New("workerpool: количество воркеров должно быть больше 0")
+	}
+	if handler == nil {
+		return nil, errors.New("workerpool: не задан обработчик")
+	}
+
+	cfg := config{ctx: context.Background(), queueSize: defaultQueueSize, latencyBuckets: DefaultLatencyBuckets}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	p := &Pool[T, R]{
+		handler: handler,
+		retry:   cfg.retry,
+		submit:  make(chan job[T]),
+		in:      make(chan job[T]),
+		results: make(chan Result[T, R], workers),
+		done:    make(chan struct{}),
+
+		queueSize: cfg.queueSize,
+		aging:     cfg.aging,
+		limiter:   cfg.limiter,
+		metrics:   newMetrics(cfg.latencyBuckets),
+		logger:    cfg.logger,
+		start:     time.Now(),
+
+		window:       cfg.window,
+		dispatchDone: make(chan struct{}),
+	}
+	p.out = p.results
+	if p.window > 0 {
+		p.out = make(chan Result[T, R], workers)
+		p.release = make(chan struct{})
+		p.reorderDone = make(chan struct{})
+		go p.reorder()
+	}
+	p.ctx, p.cancel = context.WithCancel(cfg.ctx)
+	if cfg.deadLetter {
+		p.dead = make(chan DeadLetter[T], cfg.deadLetterSize)
+	}
+
+	go p.dispatch()
+	p.grow(workers)
+
+	// когда все воркеры завершились, закрываем канал результатов,
+	// чтобы for res := range pool.Results() корректно завершился
+	go func() {
+		p.wg.Wait()
+		p.cancel()
+		close(p.out)
+		if p.reorderDone != nil {
+			<-p.reorderDone // горутина перестановки отдаст оставшиеся результаты и закроет p.results
+		}
+		if p.dead != nil {
+			close(p.dead)
+		}
+		close(p.done)
+	}()
+
+	return p, nil
+}
+
+// worker читает задачи из канала, пока он не закрыт, и отправляет результат обработчика в канал результатов.
+// канал stop проверяется только между задачами, поэтому удаляемый воркер всегда дообрабатывает текущую задачу.
+// если обработчик запаниковал, воркер отчитывается об ошибке, запускает себе замену и завершается
+func (p *Pool[T, R]) worker(id int, stop <-chan struct{}) {
+	defer p.wg.Done()
+	p.log("воркер запущен", "worker_start", id)
+	for {
+		select {
+		case <-stop: // воркер удалён через Resize
+			p.log("воркер остановлен", "worker_stop", id)
+			return
+		case j, ok := <-p.in:
+			if !ok { // пул закрыт
+				p.log("воркер остановлен", "worker_stop", id)
+				return
+			}
+			if panicked := p.process(id, j); panicked {
+				p.panics.Add(1)
+				p.log("воркер упал с паникой и будет перезапущен", "worker_panic", id)
+				p.replace(stop)
+				return
+			}
+		}
+	}
+}
+
+// process обрабатывает задачу с повторами по RetryPolicy и отправляет результат;
+// если все попытки исчерпаны, задача дополнительно уходит в dead-letter канал.
+// возвращает true, если обработчик запаниковал
+func (p *Pool[T, R]) process(id int, j job[T]) (panicked bool) {
+	var (
+		value    R
+		err      error
+		attempts int
+		limit    = p.retry.attempts()
+		started  = time.Now()
+	)
+	p.metrics.busy.Add(1)
+	for attempts = 1; ; attempts++ {
+		value, err = p.safeCall(p.ctx, j)
+		var pe *PanicError
+		if panicked = errors.As(err, &pe); panicked {
+			break
+		}
+		if err == nil || attempts >= limit || !p.retry.retryable(err) {
+			break
+		}
+		if ctxErr := sleepCtx(p.ctx, p.retry.backoff(attempts)); ctxErr != nil {
+			err = errors.Join(err, ctxErr) // пул отменён во время ожидания - повторять больше нельзя
+			break
+		}
+	}
+
+	elapsed := time.Since(started)
+	p.metrics.busy.Add(-1)
+	p.metrics.observe(id, elapsed, err != nil)
+
+	p.out <- Result[T, R]{ID: j.id, WorkerID: id, Item: j.item, Value: value, Err: err, Attempts: attempts, Duration: elapsed}
+	if err != nil && p.dead != nil {
+		p.dead <- DeadLetter[T]{ID: j.id, WorkerID: id, Item: j.item, Err: err, Attempts: attempts}
+	}
+	return panicked
+}
+
+// log пишет событие жизненного цикла воркера, если журнал включён
+func (p *Pool[T, R]) log(msg, event string, workerID int) {
+	if p.logger != nil {
+		p.logger.Info(msg, "event", event, "worker", workerID)
+	}
+}
+
+// grow запускает n новых воркеров; вызывается под sizeMu (или до того, как пул стал доступен)
+func (p *Pool[T, R]) grow(n int) {
+	for i := 0; i < n; i++ {
+		p.nextID++
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.wg.Add(1) // каждый воркер учтён в WaitGroup, поэтому Wait дождётся и добавленных позже
+		go p.worker(p.nextID, stop)
+	}
+}
+
+// shrink останавливает n последних запущенных воркеров; вызывается под sizeMu
+func (p *Pool[T, R]) shrink(n int) {
+	for i := 0; i < n; i++ {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+}
+
+// Resize меняет количество воркеров на лету: добавляет недостающих или останавливает лишних.
+// остановленные воркеры завершают текущую задачу и выходят, Wait учитывает их до самого выхода
+func (p *Pool[T, R]) Resize(workers int) error {
+	if workers <= 0 {
+		return errors.New("workerpool: количество воркеров должно быть больше 0")
+	}
+
+	// держим RLock, чтобы Close не закрыл пул посреди изменения размера
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+	switch diff := workers - len(p.stops); {
+	case diff > 0:
+		p.grow(diff)
+	case diff < 0:
+		p.shrink(-diff)
+	}
+	return nil
+}
+
+// Size возвращает текущее (целевое) количество воркеров
+func (p *Pool[T, R]) Size() int {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+	return len(p.stops)
+}
+
+// Submit отправляет задачу в пул с приоритетом PriorityNormal.
+// блокируется, пока в очереди пула нет места; после Close возвращает ErrClosed
+func (p *Pool[T, R]) Submit(item T) error {
+	return p.SubmitPriority(item, PriorityNormal)
+}
+
+// SubmitPriority отправляет задачу в пул с указанным приоритетом: воркеры всегда берут
+// задачу с наибольшим приоритетом из ожидающих, при равных приоритетах - в порядке отправки
+func (p *Pool[T, R]) SubmitPriority(item T, prio Priority) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+	p.submit <- job[T]{score: p.score(prio, time.Now()), item: item}
+	return nil
+}
+
+// Results возвращает канал результатов; он закрывается после завершения всех воркеров
+func (p *Pool[T, R]) Results() <-chan Result[T, R] {
+	return p.results
+}
+
+// DeadLetters возвращает dead-letter канал (nil, если он не включён опцией WithDeadLetter);
+// канал закрывается вместе с Results
+func (p *Pool[T, R]) DeadLetters() <-chan DeadLetter[T] {
+	return p.dead
+}
+
+// Close прекращает приём задач: воркеры дообрабатывают задачи, оставшиеся в очереди, и завершаются.
+// повторный вызов ничего не делает
+func (p *Pool[T, R]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.submit) // диспетчер раздаст остаток очереди и закроет канал воркеров
+}
+
+// Wait блокируется, пока все воркеры не завершат работу (после Close)
+func (p *Pool[T, R]) Wait() {
+	<-p.done
+}
@@ -0,0 +1,271 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task3_WorkerPoolImplementation/ratelimit"
+)
+
+// без MaxDelay задержка растёт до предела time.Duration и не переполняется в отрицательную
+func TestBackoffWithoutMaxDelayClamped(t *testing.T) {
+	rp := RetryPolicy{InitialDelay: time.Second, Multiplier: 2}
+	prev := time.Duration(0)
+	for attempt := 1; attempt <= 200; attempt++ {
+		d := rp.backoff(attempt)
+		if d < prev {
+			t.Fatalf("backoff(%d) = %v меньше предыдущей задержки %v", attempt, d, prev)
+		}
+		prev = d
+	}
+	if prev != math.MaxInt64 {
+		t.Fatalf("backoff(200) = %v, ожидалось math.MaxInt64", prev)
+	}
+
+	rp.MaxDelay = time.Minute
+	if d := rp.backoff(200); d != time.Minute {
+		t.Fatalf("с MaxDelay backoff(200) = %v, ожидалось %v", d, time.Minute)
+	}
+
+	// с джиттером и без MaxDelay задержка тоже не уходит в NaN и отрицательные значения:
+	// джиттер только уменьшает её, не больше чем на долю Jitter
+	rp = RetryPolicy{InitialDelay: time.Second, Multiplier: 2, Jitter: 0.5}
+	for attempt := 1; attempt <= 2000; attempt++ {
+		d := rp.backoff(attempt)
+		full := RetryPolicy{InitialDelay: time.Second, Multiplier: 2}.backoff(attempt)
+		if d <= 0 || d > full || float64(d) < float64(full)*0.5 {
+			t.Fatalf("backoff(%d) с джиттером = %v, ожидалось от %v до %v", attempt, d, full/2, full)
+		}
+	}
+}
+
+// ошибки обработчика распределяются по RetryPolicy: временные повторяются до MaxAttempts,
+// Permanent и ошибки, отвергнутые Retryable, сразу уходят в dead-letter канал; успешные задачи туда не попадают,
+// а канал закрывается вместе с Results
+func TestRetryAndDeadLetters(t *testing.T) {
+	errTemporary := errors.New("временная ошибка")
+	errFatal := errors.New("неповторяемая ошибка")
+	var mu sync.Mutex
+	calls := map[string]int{}
+	pool, err := New(2, func(_ context.Context, item string) (string, error) {
+		mu.Lock()
+		calls[item]++
+		n := calls[item]
+		mu.Unlock()
+		switch item {
+		case "ok":
+			return item, nil
+		case "third-time":
+			if n < 3 {
+				return "", errTemporary
+			}
+			return item, nil
+		case "permanent":
+			return "", Permanent(errTemporary)
+		case "fatal":
+			return "", errFatal
+		default: // "always"
+			return "", errTemporary
+		}
+	},
+		WithRetry(RetryPolicy{MaxAttempts: 4, InitialDelay: time.Millisecond, Multiplier: 2, Jitter: 0.5,
+			Retryable: func(err error) bool { return !errors.Is(err, errFatal) }}),
+		WithDeadLetter(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []string{"ok", "third-time", "permanent", "fatal", "always"}
+	go func() {
+		for _, item := range items {
+			if err := pool.Submit(item); err != nil {
+				t.Error(err)
+			}
+		}
+		pool.Close()
+	}()
+
+	type outcome struct {
+		attempts int
+		failed   bool
+	}
+	got := map[string]outcome{}
+	for res := range pool.Results() {
+		got[res.Item] = outcome{res.Attempts, res.Err != nil}
+	}
+	pool.Wait()
+	dead := map[string]int{}
+	for dl := range pool.DeadLetters() { // канал закрыт после Wait, цикл завершается
+		dead[dl.Item] = dl.Attempts
+		if dl.Err == nil {
+			t.Errorf("dead letter %q без ошибки", dl.Item)
+		}
+	}
+
+	want := map[string]outcome{
+		"ok":         {1, false},
+		"third-time": {3, false},
+		"permanent":  {1, true},
+		"fatal":      {1, true},
+		"always":     {4, true},
+	}
+	for item, w := range want {
+		if got[item] != w {
+			t.Errorf("%s: попыток %d, ошибка %v; ожидалось %d, %v", item, got[item].attempts, got[item].failed, w.attempts, w.failed)
+		}
+		if _, ok := dead[item]; ok != w.failed {
+			t.Errorf("%s: в dead-letter канале %v, ожидалось %v", item, ok, w.failed)
+		} else if ok && dead[item] != w.attempts {
+			t.Errorf("%s: dead letter с %d попытками, ожидалось %d", item, dead[item], w.attempts)
+		}
+	}
+}
+
+// упорядоченная выдача сохраняет порядок Submit, даже когда пул меняет размер, а обработчики паникуют
+func TestOrderedResultsUnderResizeAndPanics(t *testing.T) {
+	const n = 300
+	pool, err := New(4, func(_ context.Context, item int) (int, error) {
+		time.Sleep(time.Duration(rand.IntN(200)) * time.Microsecond)
+		if item%7 == 0 {
+			panic("кратно семи")
+		}
+		return item * 2, nil
+	}, WithOrderedResults(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for i := 1; i <= n; i++ {
+			if err := pool.Submit(i); err != nil {
+				t.Error(err)
+				return
+			}
+			if i%25 == 0 {
+				if err := pool.Resize(1 + i/25%6); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}
+		pool.Close()
+	}()
+
+	var id uint64
+	panics := 0
+	for res := range pool.Results() {
+		id++
+		if res.ID != id || res.Item != int(id) {
+			t.Fatalf("результат %d: ID=%d Item=%d, ожидался ID и Item %d", id, res.ID, res.Item, id)
+		}
+		var pe *PanicError
+		switch {
+		case res.Item%7 == 0:
+			if !errors.As(res.Err, &pe) || pe.ItemID != res.ID {
+				t.Fatalf("задача %d: ожидалась PanicError, получено %v", res.Item, res.Err)
+			}
+			panics++
+		case res.Err != nil || res.Value != res.Item*2:
+			t.Fatalf("задача %d: Value=%d Err=%v", res.Item, res.Value, res.Err)
+		}
+	}
+	pool.Wait()
+	if id != n {
+		t.Fatalf("получено %d результатов, ожидалось %d", id, n)
+	}
+	if got := pool.Panics(); got != uint64(panics) {
+		t.Fatalf("Panics() = %d, ожидалось %d", got, panics)
+	}
+}
+
+// пока единственный воркер занят, задачи копятся в очереди и затем выдаются по приоритету,
+// а при равном приоритете - в порядке отправки
+func TestPriorityOrder(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	pool, err := New(1, func(_ context.Context, item string) (string, error) {
+		if item == "blocker" {
+			once.Do(func() { close(started) })
+			<-release
+		}
+		return item, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pool.Submit("blocker"); err != nil {
+		t.Fatal(err)
+	}
+	<-started // воркер занят и больше задач не берёт - остальные остаются в очереди диспетчера
+	submits := []struct {
+		item string
+		prio Priority
+	}{
+		{"low1", PriorityLow}, {"normal1", PriorityNormal}, {"high1", PriorityHigh},
+		{"low2", PriorityLow}, {"high2", PriorityHigh}, {"normal2", PriorityNormal},
+	}
+	for _, s := range submits {
+		if err := pool.SubmitPriority(s.item, s.prio); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(release)
+	pool.Close()
+
+	var got []string
+	for res := range pool.Results() {
+		got = append(got, res.Value)
+	}
+	want := []string{"blocker", "high1", "high2", "normal1", "normal2", "low1", "low2"}
+	if len(got) != len(want) {
+		t.Fatalf("порядок %v, ожидался %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("порядок %v, ожидался %v", got, want)
+		}
+	}
+}
+
+// повышение скорости лимитера будит диспетчер, который уже ждёт разрешения: при 1/m без этого
+// вторая задача вышла бы только через минуту
+func TestRateLimitRaisedDuringWait(t *testing.T) {
+	limiter := ratelimit.New(1.0/60, 1)
+	pool, err := New(2, func(_ context.Context, item int) (int, error) { return item, nil }, WithRateLimit(limiter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 20
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := pool.Submit(i); err != nil {
+				t.Error(err)
+			}
+		}
+		pool.Close()
+	}()
+
+	<-pool.Results() // первая задача проходит по начальному токену, дальше диспетчер ждёт минуту
+	time.AfterFunc(50*time.Millisecond, func() { limiter.SetRate(1000) })
+	got := 1
+	timeout := time.After(3 * time.Second)
+	for got < n {
+		select {
+		case _, ok := <-pool.Results():
+			if !ok {
+				t.Fatalf("канал результатов закрыт после %d из %d", got, n)
+			}
+			got++
+		case <-timeout:
+			t.Fatalf("после SetRate(1000) за 3s получено %d результатов из %d", got, n)
+		}
+	}
+	pool.Wait()
+}
@@ -0,0 +1,139 @@
+package workerpool
+
+import (
+	"container/heap"
+	"time"
+)
+
+// Priority - приоритет задачи: чем больше значение, тем раньше воркеры её возьмут.
+// можно использовать любые int, константы ниже - лишь типовые уровни
+type Priority int
+
+const (
+	PriorityLow    Priority = -10
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 10
+)
+
+// defaultQueueSize - размер очереди ожидающих задач по умолчанию (см. WithQueueSize)
+const defaultQueueSize = 64
+
+// jobQueue - очередь ожидающих задач на основе кучи (container/heap).
+// порядок задаётся score: приоритет задачи за вычетом "возраста" момента постановки в очередь.
+// при включённом старении (WithAging) каждая задача с течением времени эффективно получает +1 к приоритету
+// за каждый интервал aging; поскольку все задачи стареют с одинаковой скоростью, сравнение
+// prio_a + (t - enq_a)/aging и prio_b + (t - enq_b)/aging не зависит от t, и score можно посчитать один раз
+type jobQueue[T any] []job[T]
+
+func (q jobQueue[T]) Len() int { return len(q) }
+
+func (q jobQueue[T]) Less(i, j int) bool {
+	if q[i].score != q[j].score {
+		return q[i].score > q[j].score
+	}
+	return q[i].id < q[j].id // при равном приоритете - FIFO
+}
+
+func (q jobQueue[T]) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *jobQueue[T]) Push(x any) { *q = append(*q, x.(job[T])) }
+
+func (q *jobQueue[T]) Pop() any {
+	old := *q
+	n := len(old) - 1
+	j := old[n]
+	old[n] = job[T]{} // не держим ссылку на задачу в хвосте слайса
+	*q = old[:n]
+	return j
+}
+
+// score рассчитывает ключ сортировки задачи с учётом старения
+func (p *Pool[T, R]) score(prio Priority, enqueued time.Time) float64 {
+	if p.window > 0 { // упорядоченный режим: все задачи равны, очередь работает как FIFO
+		return 0
+	}
+	if p.aging <= 0 {
+		return float64(prio)
+	}
+	return float64(prio) - float64(enqueued.Sub(p.start))/float64(p.aging)
+}
+
+// dispatch - горутина-диспетчер: принимает задачи от Submit в кучу и отдаёт воркерам задачу
+// с наибольшим приоритетом. в очереди держится не больше queueSize задач - дальше Submit блокируется.
+// если задан лимитер, каждая выдача ждёт разрешения от него, при этом приём новых задач не останавливается.
+// в упорядоченном режиме выдача дополнительно ограничена окном (см. ordered.go).
+// после Close диспетчер раздаёт оставшиеся задачи и закрывает канал воркеров
+func (p *Pool[T, R]) dispatch() {
+	defer close(p.dispatchDone)
+	defer close(p.in)
+
+	var (
+		queue   jobQueue[T]
+		lastID  uint64     // ID последней принятой задачи
+		credit  = p.window // свободные места в окне упорядоченной выдачи
+		submit  = p.submit
+		allowed = p.limiter == nil // есть разрешение лимитера на следующую выдачу
+		timer   *time.Timer        // ожидание разрешения лимитера
+		wait    <-chan time.Time
+		changed <-chan struct{} // изменение параметров лимитера во время ожидания (см. AdjustableLimiter)
+	)
+	adjustable, _ := p.limiter.(AdjustableLimiter)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for submit != nil || len(queue) > 0 {
+		// разрешение запрашиваем только когда есть что выдавать, иначе токены тратились бы впустую
+		if len(queue) > 0 && !allowed && wait == nil {
+			if adjustable != nil { // канал берём до Reserve, чтобы не пропустить изменение между ними
+				changed = adjustable.Changed()
+			}
+			if d := p.limiter.Reserve(); d <= 0 {
+				allowed, changed = true, nil
+			} else {
+				timer = time.NewTimer(d)
+				wait = timer.C
+			}
+		}
+
+		// nil-канал в select никогда не готов - так отключаем ненужные ветки
+		var (
+			accept <-chan job[T]
+			out    chan<- job[T]
+			next   job[T]
+		)
+		if len(queue) < p.queueSize {
+			accept = submit
+		}
+		if len(queue) > 0 && allowed && (p.window == 0 || credit > 0) {
+			out = p.in
+			next = queue[0]
+		}
+
+		select {
+		case j, ok := <-accept:
+			if !ok { // пул закрыт: больше задач не будет, раздаём то, что осталось
+				submit = nil
+				continue
+			}
+			lastID++
+			j.id = lastID
+			heap.Push(&queue, j)
+		case out <- next:
+			heap.Pop(&queue)
+			allowed = p.limiter == nil
+			credit--
+		case <-p.release: // nil, если упорядоченный режим выключен
+			credit++
+		case <-wait:
+			allowed, wait, changed = true, nil, nil
+		case <-changed: // лимитер изменился: старый резерв отменяем, на следующем круге запросим новый
+			timer.Stop()
+			adjustable.Cancel()
+			wait, changed = nil, nil
+		}
+		p.metrics.queueDepth.Store(int64(len(queue)))
+	}
+}
@@ -0,0 +1,104 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy описывает повторные попытки для задач, обработчик которых вернул ошибку.
+// задержка между попытками растёт экспоненциально: InitialDelay * Multiplier^(n-1), но не больше MaxDelay;
+// Jitter (от 0 до 1) случайно уменьшает каждую задержку на долю до Jitter, чтобы воркеры не повторяли запросы синхронно
+type RetryPolicy struct {
+	MaxAttempts  int           // общее число попыток, включая первую; 0 или 1 - без повторов
+	InitialDelay time.Duration // задержка перед второй попыткой
+	MaxDelay     time.Duration // верхняя граница задержки; 0 - без ограничения
+	Multiplier   float64       // во сколько раз растёт задержка; меньше 1 считается как 2
+	Jitter       float64       // доля случайного разброса задержки, от 0 до 1
+
+	// Retryable решает, имеет ли смысл повторять задачу после ошибки; nil - повторять любые ошибки,
+	// кроме обёрнутых в Permanent и ошибок отмены контекста
+	Retryable func(error) bool
+}
+
+// attempts возвращает общее число попыток с учётом значения по умолчанию
+func (rp RetryPolicy) attempts() int {
+	if rp.MaxAttempts < 1 {
+		return 1
+	}
+	return rp.MaxAttempts
+}
+
+// backoff рассчитывает задержку перед попыткой номер attempt+1
+func (rp RetryPolicy) backoff(attempt int) time.Duration {
+	mult := rp.Multiplier
+	if mult < 1 {
+		mult = 2
+	}
+	// без MaxDelay предел - наибольшая time.Duration: иначе d дорастал бы до +Inf, джиттер давал бы
+	// Inf-Inf = NaN, а NaN и значения вне int64 при переводе в time.Duration становятся отрицательными -
+	// и повтор шёл бы без паузы
+	limit := float64(math.MaxInt64)
+	if rp.MaxDelay > 0 {
+		limit = float64(rp.MaxDelay)
+	}
+	d := float64(rp.InitialDelay)
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= mult
+	}
+	d = min(d, limit)
+	if j := min(max(rp.Jitter, 0), 1); j > 0 {
+		d -= d * j * rand.Float64()
+	}
+	// float64(MaxInt64) округляется до 2^63, которое в int64 уже не помещается
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+// retryable проверяет, можно ли повторить задачу после ошибки err
+func (rp RetryPolicy) retryable(err error) bool {
+	var perm *permanentError
+	if errors.As(err, &perm) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if rp.Retryable != nil {
+		return rp.Retryable(err)
+	}
+	return true
+}
+
+// permanentError помечает ошибку, после которой задачу не нужно повторять
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent оборачивает ошибку обработчика, чтобы пул не делал повторных попыток
+// и сразу отправил задачу в dead-letter канал
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// sleepCtx ждёт d или отмены контекста; возвращает ошибку контекста, если он отменён раньше
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task3_WorkerPoolImplementation/ratelimit"
+	el "github.com/Kras0Tanya/WB-L1/internal/eventlog"
 )
 
 /* в качестве основы взяла своё решение L1.3, поскольку там уже был реализован graceful shutdown через канал;
@@ -15,34 +24,84 @@ import (
 позволяет добавлять таймауты, дедлайны или значения без изменения структуры программы
 */
 
+// режимы завершения (двухфазный graceful shutdown):
+// 1) первый Ctrl+C (SIGINT/SIGTERM) - продюсер перестаёт писать и закрывает канал, воркеры дообрабатывают то, что уже в буфере;
+// 2) повторный Ctrl+C или истечение дедлайна -grace - принудительная отмена, необработанные числа отбрасываются.
+// в конце выводится, сколько чисел отправлено, обработано и отброшено
+
 // worker запускает воркера с указанным ID, читает данные из канала и обрабатывает их.
-// добавлен ctx.Done()
+// на мягкой остановке канал закрывается продюсером, и воркер дочитывает буфер до конца;
+// ctx отменяется только при принудительной остановке - тогда воркер выходит, не дочитывая канал.
+// события воркера (запуск, обработка числа, остановка) пишутся в logger структурированными записями.
 // defer wg.Done() уменьшает счетчик WaitGroup после завершения воркера
-func worker(id int, ch <-chan int, ctx context.Context, wg *sync.WaitGroup) {
+func worker(id int, ch <-chan int, ctx context.Context, processed *atomic.Int64, logger *slog.Logger, wg *sync.WaitGroup) {
 	defer wg.Done()
+	logger.Info("воркер запущен", el.KeyEvent, el.EventWorkerStart, el.KeyWorker, id)
 	for {
+		// сначала проверяем принудительную отмену: select выбирает готовую ветку случайно,
+		// и без этой проверки воркер мог бы продолжать брать числа из буфера после отмены
+		if ctx.Err() != nil {
+			logger.Info("воркер остановлен принудительно", el.KeyEvent, el.EventWorkerStop, el.KeyWorker, id)
+			return
+		}
 		select {
 		case data, ok := <-ch:
-			if !ok { // если канал закрыт, воркер больше не может получать данные, поэтому он завершает работу
+			if !ok { // канал закрыт и буфер вычитан - всё обработано, воркер завершает работу
+				logger.Info("воркер завершил работу", el.KeyEvent, el.EventWorkerStop, el.KeyWorker, id)
 				return
 			}
-			fmt.Printf("Воркер %d обработал число: %d\n", id, data)
-		case <-ctx.Done(): // при отмене контекста (например, по Ctrl+C) канал ctx.Done() закрывается, воркер завершает работу
+			received := time.Now()
+			processed.Add(1)
+			// duration - время от получения числа из канала до конца его обработки (учёта)
+			logger.Info("воркер обработал число", el.KeyEvent, el.EventItemProcessed, el.KeyWorker, id, el.KeyItem, data,
+				el.KeyDuration, time.Since(received))
+		case <-ctx.Done(): // принудительная остановка (повторный Ctrl+C или дедлайн)
+			logger.Info("воркер остановлен принудительно", el.KeyEvent, el.EventWorkerStop, el.KeyWorker, id)
 			return
 		}
 	}
 }
 
+// controlRate меняет лимит на лету по командам из stdin, по одной в строке:
+// "rate 50/s", "rate 0" (без ограничения) или "burst 10" - набираются в терминале или подаются через pipe
+func controlRate(limiter *ratelimit.TokenBucket, logger *slog.Logger) {
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		if err := limiter.Apply(sc.Text()); err != nil {
+			logger.Error("ошибка изменения лимита", el.KeyEvent, el.EventRateChange, "error", err)
+			continue
+		}
+		logger.Info("лимит изменён", el.KeyEvent, el.EventRateChange, "rate", limiter.Rate(), "burst", limiter.Burst())
+	}
+}
+
 func main() {
+	// флаги задаются до количества воркеров: go run main4.go -grace=3s -buffer=50 --rate=100/s --burst=20 5
+	grace := flag.Duration("grace", 5*time.Second, "сколько ждать дообработки буфера после первого Ctrl+C")
+	bufSize := flag.Int("buffer", 100, "размер буфера канала данных")
+	rateStr := flag.String("rate", "", "ограничение скорости продюсера, например 100/s или 6000/m (по умолчанию без ограничения; на лету - строка \"rate 50/s\" в stdin)")
+	burst := flag.Int("burst", 1, "сколько чисел продюсер может отправить подряд без ожидания (ёмкость token bucket)")
+	format := flag.String("format", "text", el.FormatUsage)
+	flag.Parse()
+
+	// события программы пишутся структурированными записями в stdout (формат задаётся --format)
+	logger, err := el.New(os.Stdout, *format)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
 
-	// проверяем, передан ли аргумент (есть ли аргументы кроме имени программы), иначе - завершаем с кодом ошибки os.Exit(1)
-	if len(os.Args) < 2 {
-		fmt.Println("Ошибка: укажите количество воркеров (например, go run main.go 5)")
+	// проверяем, передан ли аргумент (есть ли аргументы кроме флагов), иначе - завершаем с кодом ошибки os.Exit(1)
+	if flag.NArg() < 1 {
+		fmt.Println("Ошибка: укажите количество воркеров (например, go run main4.go 5)")
 		os.Exit(1)
 	}
 
-	// получаем аргумент (количество воркеров): os.Args[1] - строка, содержащая первый аргумент (напр., "5")
-	numWorkersStr := os.Args[1]
+	// получаем аргумент (количество воркеров): flag.Arg(0) - строка, содержащая первый аргумент после флагов (напр., "5")
+	numWorkersStr := flag.Arg(0)
 	numWorkers, err := strconv.Atoi(numWorkersStr)
 	if err != nil {
 		fmt.Printf("Ошибка: %s не является числом\n", numWorkersStr)
@@ -53,12 +112,35 @@ func main() {
 		fmt.Println("Ошибка: количество воркеров должно быть больше 0")
 		os.Exit(1)
 	}
+	if *grace <= 0 || *bufSize < 0 {
+		fmt.Println("Ошибка: -grace должен быть больше 0, -buffer - не меньше 0")
+		os.Exit(1)
+	}
 
-	// Создаем контекст с возможностью отмены
-	ctx, cancel := context.WithCancel(context.Background())
+	// token bucket для продюсера; без --rate скорость не ограничена (rate = 0)
+	var rate float64
+	if *rateStr != "" {
+		if rate, err = ratelimit.ParseRate(*rateStr); err != nil {
+			fmt.Println("Ошибка:", err)
+			os.Exit(1)
+		}
+	}
+	limiter := ratelimit.New(rate, *burst)
+	go controlRate(limiter, logger) // скорость и ёмкость можно менять на лету командами из stdin
+
+	// два контекста - по одному на каждую фазу остановки:
+	// stopCtx останавливает продюсера, forceCtx принудительно останавливает воркеров
+	stopCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	forceCtx, force := context.WithCancel(context.Background())
+	defer force()
+
+	// создаем буферизованный канал для данных (в нашем примере - чисел):
+	// при мягкой остановке воркеры дообрабатывают именно содержимое буфера
+	dataCh := make(chan int, *bufSize)
 
-	// создаем канал для данных (в нашем примере - чисел)
-	dataCh := make(chan int)
+	// счётчики для итогового отчёта
+	var produced, processed atomic.Int64
 
 	// создаем WaitGroup для синхронизации
 	var wg sync.WaitGroup
@@ -66,37 +148,70 @@ func main() {
 	// запускаем воркеры (numWorkers горутин, каждая из которых вызывает функцию worker)
 	for i := 1; i <= numWorkers; i++ {
 		wg.Add(1)
-		go worker(i, dataCh, ctx, &wg)
+		go worker(i, dataCh, forceCtx, &processed, logger, &wg)
 	}
 
 	// буф.канал для сигналов завершения и сами сигналы
 	// программа завершается при нажатии Ctrl+C (сигнал SIGINT) или другом сигнале типа kill (SIGTERM).
-	sigCh := make(chan os.Signal, 1)
+	sigCh := make(chan os.Signal, 2)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	// закрывается, когда все воркеры завершились - чтобы горутина сигналов не ждала дедлайн впустую
+	workersDone := make(chan struct{})
+
 	// горутина для обработки сигналов
 	go func() {
-		<-sigCh  // ждем сигнала Ctrl+C
-		cancel() // отменяем контекст
+		sig := <-sigCh // ждем первого сигнала Ctrl+C
+		logger.Info("получен сигнал: прекращаем запись, дообрабатываем буфер (повторный Ctrl+C - немедленно)",
+			el.KeyEvent, el.EventShutdown, "signal", sig.String(), "phase", "drain", "grace", *grace)
+		stop() // фаза 1: останавливаем продюсера
+
+		timer := time.NewTimer(*grace)
+		defer timer.Stop()
+		select {
+		case sig := <-sigCh:
+			logger.Info("повторный сигнал: принудительное завершение",
+				el.KeyEvent, el.EventShutdown, "signal", sig.String(), "phase", "force")
+		case <-timer.C:
+			logger.Info("дедлайн истёк: принудительное завершение",
+				el.KeyEvent, el.EventTimeout, "phase", "force", "grace", *grace)
+		case <-workersDone: // буфер успели дообработать
+			return
+		}
+		force() // фаза 2: отменяем контекст воркеров
 	}()
 
 	// горутина для постоянной записи данных в канал
-	// добавлен ctx.Done()
+	// отправка тоже выбирает по stopCtx.Done(), поэтому продюсер не зависнет на dataCh <- i после отмены
 	go func() {
+		defer close(dataCh) // закрываем канал данных - воркеры дочитают буфер и завершатся
 		for i := 1; ; i++ {
+			// ждём токен; ожидание тоже прерывается остановкой
+			if err := limiter.Wait(stopCtx); err != nil {
+				return
+			}
 			select {
-			case <-ctx.Done(): // если контекст отменён
-				close(dataCh) // закрываем канал данных
+			case <-stopCtx.Done(): // если остановка запрошена
 				return
-			default:
-				dataCh <- i // записываем данные
+			case dataCh <- i: // записываем данные
+				produced.Add(1)
 			}
 		}
 	}()
 
 	// ждем завершения воркеров
 	wg.Wait()
-	fmt.Println("Все воркеры завершили работу!")
+	close(workersDone)
+
+	// всё, что осталось в канале после выхода воркеров, отброшено; продюсер к этому моменту
+	// уже остановлен (forceCtx отменяется только после stopCtx), так что канал будет закрыт
+	dropped := 0
+	for range dataCh {
+		dropped++
+	}
+
+	logger.Info("все воркеры завершили работу", el.KeyEvent, el.EventSummary,
+		"produced", produced.Load(), "processed", processed.Load(), "dropped", dropped)
 }
 
 /*
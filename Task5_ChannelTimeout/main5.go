@@ -2,59 +2,121 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
-	"strconv"
 	"sync"
 	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/clock"
+	el "github.com/Kras0Tanya/WB-L1/internal/eventlog"
 )
 
 /* в качестве основы взяла своё решение L1.4, поскольку там уже был реализован context;
 здесь же добавила таймаут, в соответствии с условиями задачи (см. фабулу ниже кода)
 */
 
-// reader читает значения из канала и выводит их в stdout
-// завершается при закрытии канала или отмене контекста
-func reader(ch <-chan int, ctx context.Context, wg *sync.WaitGroup) {
+// reader читает значения из канала и пишет событие о каждом значении в logger.
+// завершается при закрытии канала, отмене контекста (общий дедлайн --total) или если за idle
+// не пришло ни одного значения (--idle; 0 - ждать сколько угодно). уходя по idle, ридер вызывает cancel,
+// чтобы писатель тоже остановился. таймер простоя и замеры времени берутся из clk
+func reader(ch <-chan int, ctx context.Context, idle time.Duration, cancel context.CancelFunc, clk clock.Clock, logger *slog.Logger, wg *sync.WaitGroup) {
 	defer wg.Done()
+	logger.Info("ридер запущен", el.KeyEvent, el.EventWorkerStart, el.KeyWorker, 1)
+
+	// таймер простоя перезапускается после каждого полученного значения; nil-канал в select никогда не готов,
+	// поэтому без --idle ветка таймера просто не срабатывает
+	var (
+		timer clock.Timer
+		idleC <-chan time.Time
+	)
+	if idle > 0 {
+		timer = clk.NewTimer(idle)
+		defer timer.Stop()
+		idleC = timer.C()
+	}
+
+	last := clk.Now() // момент получения предыдущего значения - duration показывает, сколько ридер ждал
 	for {
 		select {
 		case data, ok := <-ch:
 			if !ok { // если канал закрыт, он завершает работу
+				logger.Info("канал закрыт, ридер завершает работу", el.KeyEvent, el.EventWorkerStop, el.KeyWorker, 1)
 				return
 			}
-			fmt.Printf("Горутина получила значение: %d\n", data)
-		case <-ctx.Done(): // контекст отменён (таймаут или ошибка)
+			// таймер перезапускается до записи события: к моменту, когда значение видно в логе,
+			// отсчёт простоя уже идёт от него (на это опираются тесты с clock.Fake)
+			now := clk.Now()
+			if timer != nil {
+				if !timer.Stop() { // таймер успел сработать одновременно со значением - вычищаем канал
+					select {
+					case <-timer.C():
+					default:
+					}
+				}
+				timer.Reset(idle)
+			}
+			logger.Info("горутина получила значение", el.KeyEvent, el.EventItemProcessed,
+				el.KeyWorker, 1, el.KeyItem, data, el.KeyDuration, now.Sub(last))
+			last = now
+		case <-idleC: // за idle не пришло ни одного значения
+			logger.Info("значений нет дольше --idle, ридер завершает работу", el.KeyEvent, el.EventTimeout,
+				el.KeyWorker, 1, "limit", "idle", el.KeyDuration, clk.Now().Sub(last))
+			cancel()
+			return
+		case <-ctx.Done(): // контекст отменён (общий дедлайн или ошибка)
+			if errors.Is(context.Cause(ctx), context.DeadlineExceeded) { // Cause - дедлайн по часам clk (clock.WithTimeout)
+				logger.Info("общий дедлайн истёк, ридер завершает работу", el.KeyEvent, el.EventTimeout, el.KeyWorker, 1, "limit", "total")
+			} else {
+				logger.Info("контекст отменён, ридер завершает работу", el.KeyEvent, el.EventWorkerStop, el.KeyWorker, 1)
+			}
 			return
 		}
 	}
 }
 
-func main() {
+// writer отправляет в ch числа 1, 2, ... с паузой pause, пока не отменён ctx, и закрывает ch, уходя.
+// и отправка, и пауза ждут вместе с ctx.Done(): ридер может уйти в любой момент (дедлайн, --idle),
+// и отправка без select заблокировалась бы навсегда - wg.Wait() в main не вернулся бы
+func writer(ch chan<- int, ctx context.Context, pause time.Duration, clk clock.Clock, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer close(ch) // ридер, ещё ждущий значения, увидит закрытие канала
 
-	// проверяем, передан ли аргумент для таймаута
-	if len(os.Args) < 2 {
-		fmt.Println("Ошибка: укажите количество секунд для таймаута (например, go run main.go 5)")
-		os.Exit(1)
-	}
+	// таймер паузы заводится только после первой отправки: заведённый заранее, он мог бы сработать,
+	// пока отправка ждёт ридера, и тогда первая пауза прошла бы мгновенно (Reset не вычищает канал)
+	var timer clock.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for i := 1; ; i++ {
+		select {
+		case ch <- i: // отправляем число в канал
+		case <-ctx.Done(): // контекст отменён (дедлайн или простой ридера)
+			return
+		}
 
-	// получаем таймаут из аргумента командной строки
-	timeoutSecondsStr := os.Args[1]
-	timeoutSeconds, err := strconv.Atoi(timeoutSecondsStr)
-	if err != nil {
-		fmt.Printf("Ошибка: %s не является числом\n", timeoutSecondsStr)
-		os.Exit(1)
-	}
-	if timeoutSeconds <= 0 {
-		fmt.Println("Ошибка: количество секунд должно быть больше 0")
-		os.Exit(1)
+		// задержка для наглядности вывода; таймер после срабатывания вычитан, поэтому Reset без вычистки канала
+		if timer == nil {
+			timer = clk.NewTimer(pause)
+		} else {
+			timer.Reset(pause)
+		}
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			return
+		}
 	}
+}
 
-	// преобразуем таймаут в time.Duration
-	timeout := time.Duration(timeoutSeconds) * time.Second
-
-	// создаем контекст с таймаутом
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// run запускает ридер и писатель с общим дедлайном total и ждёт завершения обоих
+func run(clk clock.Clock, idle, total, pause time.Duration, logger *slog.Logger) {
+	// создаем контекст с общим дедлайном; ридер отменяет его и сам, если сработал --idle
+	ctx, cancel := clock.WithTimeout(context.Background(), clk, total)
 	defer cancel() // гарантируем вызов cancel для освобождения ресурсов
 
 	// создаем канал для данных (в нашем примере - чисел)
@@ -62,30 +124,41 @@ func main() {
 
 	// создаем WaitGroup для синхронизации
 	var wg sync.WaitGroup
-
-	// запускаем ридер
-	wg.Add(1)
-	go reader(dataCh, ctx, &wg)
-
-	// запускаем горутину для записи чисел в канал
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for i := 1; ; i++ {
-			select {
-			case <-ctx.Done(): // контекст отменён (таймаут)
-				close(dataCh) // закрываем канал данных
-				return
-			default:
-				dataCh <- i                        // отправляем число в канал
-				time.Sleep(500 * time.Millisecond) // задержка для наглядности вывода
-			}
-		}
-	}()
+	wg.Add(2)
+	go reader(dataCh, ctx, idle, cancel, clk, logger, &wg)
+	go writer(dataCh, ctx, pause, clk, &wg)
 
 	// ждем завершения всех горутин
 	wg.Wait()
-	fmt.Println("Программа завершена после таймаута")
+}
+
+func main() {
+	// оба ограничения задаются длительностями Go: go run main5.go --idle=750ms --total=2m
+	format := flag.String("format", "text", el.FormatUsage)
+	idle := flag.Duration("idle", 0, "ридер завершается, если за это время не пришло ни одного значения (0 - без ограничения)")
+	total := flag.Duration("total", 5*time.Second, "общий дедлайн работы программы")
+	flag.Parse()
+
+	// события программы пишутся структурированными записями в stdout (формат задаётся --format)
+	logger, err := el.New(os.Stdout, *format)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
+
+	// раньше таймаут передавался числом секунд в аргументе - подсказываем новый синтаксис
+	if flag.NArg() > 0 {
+		fmt.Printf("Ошибка: лишний аргумент %q; таймауты задаются флагами, например --total=%ss\n", flag.Arg(0), flag.Arg(0))
+		os.Exit(1)
+	}
+	if *total <= 0 || *idle < 0 {
+		fmt.Println("Ошибка: --total должен быть больше 0, --idle - не меньше 0")
+		os.Exit(1)
+	}
+
+	// все ожидания программы идут через clk: в тестах (main5_test.go) run получает clock.Fake и проходит сценарии мгновенно
+	run(clock.Real(), *idle, *total, 500*time.Millisecond, logger)
+	logger.Info("программа завершена", el.KeyEvent, el.EventShutdown, "idle", *idle, "total", *total)
 }
 
 /*
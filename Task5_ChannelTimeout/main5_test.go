@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math/rand"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/clock"
+	el "github.com/Kras0Tanya/WB-L1/internal/eventlog"
+)
+
+// event - запись лога, из которой тесту нужны имя события, значение и причина таймаута
+type event struct {
+	Event string `json:"event"`
+	Item  int    `json:"item"`
+	Limit string `json:"limit"`
+}
+
+// eventSink - io.Writer для JSON-логгера: каждая запись slog приходит одним Write и разбирается в event
+type eventSink struct {
+	t  *testing.T
+	ch chan event
+}
+
+func (s eventSink) Write(p []byte) (int, error) {
+	var e event
+	if err := json.Unmarshal(p, &e); err != nil {
+		s.t.Errorf("некорректная запись лога %q: %v", p, err)
+	}
+	s.ch <- e
+	return len(p), nil
+}
+
+// next возвращает следующее событие с именем name, пропуская остальные
+func next(t *testing.T, events <-chan event, name string) event {
+	t.Helper()
+	for {
+		select {
+		case e := <-events:
+			if e.Event == name {
+				return e
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("не дождались события %s", name)
+		}
+	}
+}
+
+// run на ручных часах: время сдвигается ровно до следующего момента - отправки писателя,
+// срабатывания --idle или общего дедлайна, поэтому число полученных значений и причина остановки
+// всегда одинаковы, а прогон занимает миллисекунды вместо секунд
+func TestRunFakeClock(t *testing.T) {
+	cases := []struct {
+		name               string
+		idle, total, pause time.Duration
+		items              int    // сколько значений получит ридер
+		limit              string // какое ограничение его остановит
+	}{
+		{"общий дедлайн", 0, time.Second, 300 * time.Millisecond, 4, "total"},
+		{"значения приходят чаще --idle", 500 * time.Millisecond, time.Second, 300 * time.Millisecond, 4, "total"},
+		{"простой дольше --idle", 500 * time.Millisecond, 10 * time.Second, time.Second, 1, "idle"},
+		{"дедлайн раньше первой паузы", 0, 200 * time.Millisecond, time.Second, 1, "total"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			clk := clock.NewFake(start)
+			events := make(chan event, 16)
+			logger, err := el.New(eventSink{t, events}, "json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				run(clk, c.idle, c.total, c.pause, logger)
+			}()
+
+			// таймеры, которые ждут, пока ридер и писатель стоят между значениями:
+			// общий дедлайн, пауза писателя и (с --idle) таймер простоя ридера
+			waiters := 2
+			if c.idle > 0 {
+				waiters++
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			for i := 1; i <= c.items; i++ {
+				if e := next(t, events, el.EventItemProcessed); e.Item != i {
+					t.Fatalf("получено значение %d, ожидалось %d", e.Item, i)
+				}
+				if err := clk.BlockUntil(ctx, waiters); err != nil {
+					t.Fatalf("после значения %d таймеры не заведены: %v", i, err)
+				}
+				// следующий момент - ближайший из отправки, простоя и дедлайна
+				now := clk.Now().Sub(start)
+				to := min(now+c.pause, c.total)
+				if c.idle > 0 {
+					to = min(to, now+c.idle)
+				}
+				clk.Advance(to - now)
+			}
+
+			if e := next(t, events, el.EventTimeout); e.Limit != c.limit {
+				t.Fatalf("ридер остановлен по %q, ожидалось %q", e.Limit, c.limit)
+			}
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("run не вернулся после остановки ридера")
+			}
+			// лишних значений после остановки нет: все оставшиеся события - не item_processed
+			close(events)
+			for e := range events {
+				if e.Event == el.EventItemProcessed {
+					t.Fatalf("после остановки получено значение %d", e.Item)
+				}
+			}
+		})
+	}
+}
+
+// пока первая отправка ждёт ридера, пауза писателя не идёт: после того как значение забрали,
+// следующее приходит только через полную паузу (заведённый заранее таймер успел бы сработать и пропустить её)
+func TestWriterPauseAfterBlockedSend(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go writer(ch, ctx, time.Second, clk, &wg)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// ридер долго не забирает первое значение. короткое настоящее ожидание даёт писателю дойти до отправки -
+	// без него часы могли бы сдвинуться раньше, чем писатель вообще начал работу, и проверять было бы нечего
+	time.Sleep(10 * time.Millisecond)
+	clk.Advance(time.Hour)
+	if v := <-ch; v != 1 {
+		t.Fatalf("первое значение %d", v)
+	}
+	bctx, bcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer bcancel()
+	if err := clk.BlockUntil(bctx, 1); err != nil {
+		t.Fatalf("писатель не завёл паузу: %v", err)
+	}
+	clk.Advance(time.Second - time.Nanosecond)
+	select {
+	case v := <-ch:
+		t.Fatalf("значение %d пришло раньше, чем прошла пауза", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clk.Advance(time.Nanosecond)
+	if v := <-ch; v != 2 {
+		t.Fatalf("второе значение %d", v)
+	}
+}
+
+// сотни прогонов run на настоящих часах со случайными idle, total и паузой писателя (единицы миллисекунд,
+// чтобы дедлайн и --idle срабатывали в разные моменты отправки и приёма): каждый прогон должен вернуться
+// вовремя, а число горутин - к исходному. при ошибке печатаются стеки оставшихся горутин
+func TestWriterReaderNoLeak(t *testing.T) {
+	iterations := 300
+	if testing.Short() {
+		iterations = 30
+	}
+	seed := time.Now().UnixNano()
+	rnd := rand.New(rand.NewSource(seed))
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	base := runtime.NumGoroutine()
+	for i := 1; i <= iterations; i++ {
+		total := time.Duration(1+rnd.Intn(20)) * time.Millisecond
+		var idle time.Duration
+		if rnd.Intn(2) == 0 {
+			idle = time.Duration(rnd.Intn(10)) * time.Millisecond
+		}
+		pause := time.Duration(rnd.Intn(3000)) * time.Microsecond
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			run(clock.Real(), idle, total, pause, logger)
+		}()
+		select {
+		case <-done:
+		case <-time.After(total + time.Second): // с запасом: run ждёт не дольше дедлайна
+			t.Fatalf("прогон %d (seed %d, idle=%v total=%v pause=%v) завис:\n%s", i, seed, idle, total, pause, goroutines())
+		}
+		// горутины, уже вызвавшие wg.Done, могут ещё не успеть завершиться - даём им немного времени
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if left := runtime.NumGoroutine() - base; left > 0 {
+			t.Fatalf("прогон %d (seed %d, idle=%v total=%v pause=%v): осталось лишних горутин: %d\n%s",
+				i, seed, idle, total, pause, left, goroutines())
+		}
+	}
+}
+
+// goroutines возвращает стеки всех горутин
+func goroutines() string {
+	var b strings.Builder
+	pprof.Lookup("goroutine").WriteTo(&b, 1)
+	return b.String()
+}
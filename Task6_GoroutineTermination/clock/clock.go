@@ -0,0 +1,79 @@
+// Package clock - источник времени, который можно подменить. программы получают Clock параметром,
+// в работе используют Real(), а для проверок - Fake, время которого двигается только явно (Advance),
+// поэтому сценарий с таймаутом в 2 секунды проверяется мгновенно и всегда одинаково.
+// пакет публичный: Clock входит в API stopper (Worker.Clock, Watcher.Clock), и тесты в других модулях
+// подставляют туда Fake
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock - операции со временем, которые нужны таймаутам и таймерам программ
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer - аналог *time.Timer; канал доступен через метод, потому что у подделки он свой
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker - аналог *time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+	Reset(d time.Duration)
+}
+
+// Real возвращает настоящие часы (пакет time)
+func Real() Clock {
+	return realClock{}
+}
+
+// WithTimeout - context.WithTimeout по часам c: контекст отменяется, когда c.After(d) сработает.
+// для Real это и есть context.WithTimeout; для остальных часов ctx.Err() после дедлайна - context.Canceled,
+// а context.Cause(ctx) - context.DeadlineExceeded, поэтому причину нужно проверять через Cause
+func WithTimeout(parent context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := c.(realClock); ok {
+		return context.WithTimeout(parent, d)
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	timer := c.NewTimer(d)
+	go func() {
+		select {
+		case <-timer.C():
+			cancel(context.DeadlineExceeded)
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) NewTimer(d time.Duration) Timer         { return realTimer{time.NewTimer(d)} }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time   { return t.t.C }
+func (t realTicker) Stop()                 { t.t.Stop() }
+func (t realTicker) Reset(d time.Duration) { t.t.Reset(d) }
@@ -0,0 +1,198 @@
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fake - ручные часы: время стоит на месте, пока его не сдвинут Advance или Set.
+// таймеры, тикеры, After и Sleep срабатывают, когда время доходит до их момента.
+// как и у настоящих таймеров, канал срабатывания вмещает одно значение: если его не вычитали,
+// следующие тики тикера пропадают
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer  // активные таймеры и тикеры
+	changed chan struct{} // закрывается и пересоздаётся при каждом изменении набора таймеров (для BlockUntil)
+}
+
+var _ Clock = (*Fake)(nil)
+
+// NewFake создаёт ручные часы, показывающие start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start, changed: make(chan struct{})}
+}
+
+// fakeTimer - таймер (period = 0) или тикер ручных часов
+type fakeTimer struct {
+	f      *Fake
+	ch     chan time.Time
+	when   time.Time
+	period time.Duration
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// Sleep блокируется, пока часы не сдвинут на d вперёд
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: интервал тикера должен быть больше 0")
+	}
+	t := &fakeTimer{f: f, ch: make(chan time.Time, 1), period: d}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t.when = f.now.Add(d)
+	f.add(t)
+	return fakeTicker{t}
+}
+
+// Advance сдвигает время на d, по порядку срабатывая все таймеры, чей момент наступил
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set переводит часы на момент to (назад время не идёт), срабатывая таймеры по порядку
+func (f *Fake) Set(to time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for {
+		next := f.earliest()
+		if next == nil || next.when.After(to) {
+			break
+		}
+		f.now = next.when
+		select {
+		case next.ch <- f.now:
+		default: // предыдущее срабатывание не вычитано - как у time.Ticker, тик теряется
+		}
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+		} else {
+			f.remove(next)
+		}
+	}
+	if to.After(f.now) {
+		f.now = to
+	}
+}
+
+// Waiters возвращает количество активных таймеров и тикеров (в том числе ждущих After и Sleep)
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.timers)
+}
+
+// BlockUntil ждёт, пока активных таймеров станет не меньше n: так управляющий код узнаёт,
+// что проверяемые горутины дошли до Sleep или select с таймером, и время можно двигать дальше
+func (f *Fake) BlockUntil(ctx context.Context, n int) error {
+	for {
+		f.mu.Lock()
+		count, changed := len(f.timers), f.changed
+		f.mu.Unlock()
+		if count >= n {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// earliest возвращает таймер с ближайшим моментом срабатывания (при равных - заведённый раньше); вызывается под mu
+func (f *Fake) earliest() *fakeTimer {
+	var first *fakeTimer
+	for _, t := range f.timers {
+		if first == nil || t.when.Before(first.when) {
+			first = t
+		}
+	}
+	return first
+}
+
+// add и remove меняют набор активных таймеров и будят BlockUntil; вызываются под mu
+func (f *Fake) add(t *fakeTimer) {
+	f.timers = append(f.timers, t)
+	f.notify()
+}
+
+func (f *Fake) remove(t *fakeTimer) bool {
+	for i, other := range f.timers {
+		if other == t {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			f.notify()
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	return t.f.remove(t)
+}
+
+// Reset перезапускает таймер на d от текущего времени часов; d <= 0 - срабатывает сразу.
+// несработавшее значение в канале не вычищается - как у time.Timer до Go 1.23
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	active := t.f.remove(t)
+	t.when = t.f.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.ch <- t.f.now:
+		default:
+		}
+		return active
+	}
+	t.f.add(t)
+	return active
+}
+
+// fakeTicker - тикер ручных часов; отдельный тип, потому что у Ticker другие сигнатуры Stop и Reset
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time { return t.t.ch }
+func (t fakeTicker) Stop()               { t.t.Stop() }
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: интервал тикера должен быть больше 0")
+	}
+	f := t.t.f
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.remove(t.t)
+	t.t.period, t.t.when = d, f.now.Add(d)
+	f.add(t.t)
+}
@@ -0,0 +1,222 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fired возвращает значение из канала, если оно там есть, не дожидаясь его
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case v := <-ch:
+		return v, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// Advance проходит моменты таймеров по порядку: каждый срабатывает со своим временем, а не с конечным,
+// и срабатывают только те, чей момент наступил
+func TestFakeAdvanceOrder(t *testing.T) {
+	f := NewFake(start)
+	durations := []time.Duration{3 * time.Second, time.Second, 2 * time.Second, time.Second}
+	timers := make([]Timer, len(durations))
+	for i, d := range durations {
+		timers[i] = f.NewTimer(d)
+	}
+	check := func(now time.Duration) {
+		t.Helper()
+		for i, d := range durations {
+			v, ok := fired(timers[i].C())
+			switch {
+			case d > now && ok:
+				t.Fatalf("таймер на +%v сработал на +%v", d, now)
+			case d <= now && d > now-time.Second && (!ok || !v.Equal(start.Add(d))):
+				t.Fatalf("таймер на +%v: сработал=%v в +%v", d, ok, v.Sub(start))
+			}
+		}
+	}
+
+	f.Advance(1500 * time.Millisecond)
+	if got := f.Now(); !got.Equal(start.Add(1500 * time.Millisecond)) {
+		t.Fatalf("Now() = +%v, ожидалось +1.5s", got.Sub(start))
+	}
+	check(1500 * time.Millisecond)
+	if n := f.Waiters(); n != 2 {
+		t.Fatalf("Waiters() = %d, ожидалось 2", n)
+	}
+	f.Advance(1500 * time.Millisecond)
+	check(3 * time.Second)
+	if n := f.Waiters(); n != 0 {
+		t.Fatalf("Waiters() = %d, ожидалось 0", n)
+	}
+
+	// тикер, заведённый после таймера, всё равно срабатывает раньше него (+1s), а его второй тик
+	// на +2s пропадает, потому что первый не вычитан
+	f = NewFake(start)
+	tm := f.NewTimer(2 * time.Second)
+	tk := f.NewTicker(time.Second)
+	f.Set(start.Add(2 * time.Second))
+	if v, ok := fired(tm.C()); !ok || !v.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("таймер: %v %v", v, ok)
+	}
+	if v, ok := fired(tk.C()); !ok || !v.Equal(start.Add(time.Second)) {
+		t.Fatalf("тикер должен хранить первый тик (+1s), получено +%v %v", v.Sub(start), ok)
+	}
+	tk.Stop()
+
+	// назад время не идёт
+	f.Set(start)
+	if got := f.Now(); !got.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("после Set в прошлое Now() = +%v", got.Sub(start))
+	}
+}
+
+func TestFakeTimerStopReset(t *testing.T) {
+	f := NewFake(start)
+	tm := f.NewTimer(time.Second)
+	if !tm.Stop() {
+		t.Fatal("Stop активного таймера вернул false")
+	}
+	if tm.Stop() {
+		t.Fatal("повторный Stop вернул true")
+	}
+	f.Advance(time.Hour)
+	if _, ok := fired(tm.C()); ok {
+		t.Fatal("остановленный таймер сработал")
+	}
+
+	// Reset отсчитывает d от текущего времени часов
+	if tm.Reset(time.Second) {
+		t.Fatal("Reset остановленного таймера вернул true")
+	}
+	f.Advance(999 * time.Millisecond)
+	if _, ok := fired(tm.C()); ok {
+		t.Fatal("таймер сработал раньше срока")
+	}
+	if !tm.Reset(time.Second) {
+		t.Fatal("Reset активного таймера вернул false")
+	}
+	f.Advance(999 * time.Millisecond)
+	if _, ok := fired(tm.C()); ok {
+		t.Fatal("Reset не перенёс срабатывание")
+	}
+	f.Advance(time.Millisecond)
+	if v, ok := fired(tm.C()); !ok || !v.Equal(f.Now()) {
+		t.Fatalf("таймер не сработал вовремя: %v %v", v, ok)
+	}
+	if f.Waiters() != 0 {
+		t.Fatal("сработавший таймер остался среди активных")
+	}
+
+	// как у time.Timer до Go 1.23: невычитанное срабатывание остаётся в канале и после Reset
+	f.Advance(time.Hour)
+	tm.Reset(time.Second)
+	f.Advance(time.Second)
+	tm.Reset(time.Second)
+	if _, ok := fired(tm.C()); !ok {
+		t.Fatal("Reset вычистил канал")
+	}
+
+	// d <= 0 - срабатывает сразу, без сдвига часов
+	tm.Reset(0)
+	if v, ok := fired(tm.C()); !ok || !v.Equal(f.Now()) {
+		t.Fatalf("Reset(0): %v %v", v, ok)
+	}
+}
+
+// невычитанный тик занимает канал, и следующие тики пропадают, а не копятся
+func TestFakeTickerDrop(t *testing.T) {
+	f := NewFake(start)
+	tk := f.NewTicker(time.Second)
+	f.Advance(5 * time.Second)
+	if v, ok := fired(tk.C()); !ok || !v.Equal(start.Add(time.Second)) {
+		t.Fatalf("первый тик: +%v %v", v.Sub(start), ok)
+	}
+	if _, ok := fired(tk.C()); ok {
+		t.Fatal("тики накопились в канале")
+	}
+	f.Advance(time.Second)
+	if v, ok := fired(tk.C()); !ok || !v.Equal(start.Add(6*time.Second)) {
+		t.Fatalf("тик после вычитывания: +%v %v", v.Sub(start), ok)
+	}
+
+	tk.Reset(10 * time.Second)
+	f.Advance(9 * time.Second)
+	if _, ok := fired(tk.C()); ok {
+		t.Fatal("тикер сработал раньше нового интервала")
+	}
+	f.Advance(time.Second)
+	if _, ok := fired(tk.C()); !ok {
+		t.Fatal("тикер не сработал с новым интервалом")
+	}
+	tk.Stop()
+	f.Advance(time.Hour)
+	if _, ok := fired(tk.C()); ok || f.Waiters() != 0 {
+		t.Fatal("остановленный тикер продолжает работать")
+	}
+}
+
+// BlockUntil дожидается, пока горутины заведут таймеры, и только после этого время сдвигается -
+// иначе Advance мог бы пройти раньше, чем Sleep начал ждать
+func TestFakeBlockUntil(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			f.Sleep(time.Minute)
+			done <- struct{}{}
+		}()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := f.BlockUntil(ctx, 3); err != nil {
+		t.Fatalf("горутины не дошли до Sleep: %v", err)
+	}
+	f.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+
+	// никто не ждёт - BlockUntil возвращает ошибку контекста
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := f.BlockUntil(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("BlockUntil без ожидающих: %v", err)
+	}
+}
+
+// WithTimeout на ручных часах отменяется только сдвигом времени, причина - DeadlineExceeded
+func TestWithTimeoutFake(t *testing.T) {
+	f := NewFake(start)
+	ctx, cancel := WithTimeout(context.Background(), f, time.Second)
+	defer cancel()
+	f.Advance(999 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatal("контекст отменён раньше дедлайна")
+	}
+	f.Advance(time.Millisecond)
+	<-ctx.Done()
+	if !errors.Is(context.Cause(ctx), context.DeadlineExceeded) {
+		t.Fatalf("причина отмены %v, ожидалась DeadlineExceeded", context.Cause(ctx))
+	}
+
+	// отмена до дедлайна снимает таймер
+	ctx, cancel = WithTimeout(context.Background(), f, time.Second)
+	cancel()
+	if !errors.Is(context.Cause(ctx), context.Canceled) {
+		t.Fatalf("причина отмены %v, ожидалась Canceled", context.Cause(ctx))
+	}
+	bctx, bcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer bcancel()
+	for f.Waiters() != 0 && bctx.Err() == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if f.Waiters() != 0 {
+		t.Fatal("таймер отменённого контекста остался активным")
+	}
+}
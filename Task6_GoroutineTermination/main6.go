@@ -2,14 +2,19 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/clock"
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/stopper"
 )
 
 /*
@@ -24,6 +29,9 @@ import (
 - таймер (workerTimer с time.After)
 - сигналы ОС (workerSignal)
 чаще всего используют контексты или каналы уведомления (считаются идиоматичными и гибкими способами)
+
+те же способы собраны в пакете stopper как стратегии одного воркера (stopper.Worker): их можно выбирать
+флагом -stop и комбинировать - воркер останавливается по первой сработавшей (см. runStopper)
 */
 
 // workerCondition завершает горутину по условию (флаг stop с atomic.Bool)
@@ -92,10 +100,10 @@ func workerChannelClose(id int, ch <-chan int, wg *sync.WaitGroup) {
 	fmt.Printf("Воркер %d (закрытие канала): канал закрыт, завершаю работу\n", id)
 }
 
-// workerTimer завершает горутину по таймауту через time.After
-func workerTimer(id int, wg *sync.WaitGroup) {
+// workerTimer завершает горутину по таймауту через After часов clk (в обычном запуске - time.After, в тестах - clock.Fake)
+func workerTimer(id int, clk clock.Clock, wg *sync.WaitGroup) {
 	defer wg.Done()
-	timeout := time.After(2 * time.Second) // таймер на 2 секунды
+	timeout := clk.After(2 * time.Second) // таймер на 2 секунды
 	for i := 1; ; i++ {
 		select {
 		case <-timeout: // таймаут истек
@@ -103,7 +111,7 @@ func workerTimer(id int, wg *sync.WaitGroup) {
 			return
 		default:
 			fmt.Printf("Воркер %d (таймер): обработал число %d\n", id, i)
-			time.Sleep(500 * time.Millisecond)
+			clk.Sleep(500 * time.Millisecond)
 		}
 	}
 }
@@ -123,7 +131,157 @@ func workerSignal(id int, sigCh <-chan os.Signal, wg *sync.WaitGroup) {
 	}
 }
 
+// stopSpec - стратегия остановки из флага -stop и то, что нужно для её срабатывания
+type stopSpec struct {
+	strategy  stopper.Strategy
+	triggers  []func() // "ручные" стратегии (flag, done, ctx, close) срабатывают через -after
+	input     chan int // входной канал для close; nil - воркер просто считает шаги
+	closeReq  chan struct{}
+	closeOnce sync.Once // closeReq закрывают и триггер, и runStopper после остановки воркера
+}
+
+// requestClose просит генератор закрыть входной канал и завершиться; повторные вызовы ничего не делают
+func (s *stopSpec) requestClose() {
+	s.closeOnce.Do(func() { close(s.closeReq) })
+}
+
+// parseStop разбирает список стратегий через запятую: flag, done, ctx, close, timer:D, signal
+// и goexit[:СТРАТЕГИЯ] - выход через runtime.Goexit по указанной стратегии (просто goexit - по флагу)
+func parseStop(spec string) (*stopSpec, error) {
+	s := &stopSpec{}
+	var strategies []stopper.Strategy
+	for _, tok := range strings.Split(spec, ",") {
+		st, err := s.parseOne(strings.TrimSpace(tok))
+		if err != nil {
+			return nil, err
+		}
+		strategies = append(strategies, st)
+	}
+	s.strategy = stopper.Any(strategies...)
+	return s, nil
+}
+
+func (s *stopSpec) parseOne(tok string) (stopper.Strategy, error) {
+	name, arg, _ := strings.Cut(tok, ":")
+	switch name {
+	case "flag":
+		var stop atomic.Bool
+		s.triggers = append(s.triggers, func() { stop.Store(true) })
+		return stopper.Flag(&stop), nil
+	case "done":
+		done := make(chan struct{})
+		s.triggers = append(s.triggers, func() { close(done) })
+		return stopper.Done(done), nil
+	case "ctx":
+		ctx, cancel := context.WithCancel(context.Background())
+		s.triggers = append(s.triggers, cancel)
+		return stopper.Context(ctx), nil
+	case "close":
+		if s.input != nil {
+			return nil, fmt.Errorf("стратегия close указана дважды")
+		}
+		// входной канал закрывает генератор (см. runStopper), когда срабатывает триггер:
+		// закрывать канал должен тот, кто в него пишет
+		s.input, s.closeReq = make(chan int), make(chan struct{})
+		s.triggers = append(s.triggers, s.requestClose)
+		return stopper.StrategyFunc(func(*stopper.Watcher) {}), nil // останавливает шаг Range, а не стратегия
+	case "timer":
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("стратегия %q: ожидается положительная длительность, например timer:2s", tok)
+		}
+		return stopper.Timer(d), nil
+	case "signal":
+		return stopper.Signal(syscall.SIGINT, syscall.SIGTERM), nil
+	case "goexit":
+		if arg == "" {
+			arg = "flag"
+		}
+		if arg == "close" { // закрытие канала завершает шаг воркера, а не стратегию - оборачивать нечего
+			return nil, fmt.Errorf("стратегия %q: закрытие входного канала нельзя совместить с goexit", tok)
+		}
+		inner, err := s.parseOne(arg)
+		if err != nil {
+			return nil, err
+		}
+		return stopper.Goexit(inner), nil
+	}
+	return nil, fmt.Errorf("неизвестная стратегия %q (допустимо: flag, done, ctx, close, timer:D, signal, goexit[:СТРАТЕГИЯ])", tok)
+}
+
+// runStopper запускает один stopper.Worker со стратегиями из -stop; ручные стратегии срабатывают через after
+func runStopper(spec string, after time.Duration) error {
+	s, err := parseStop(spec)
+	if err != nil {
+		return err
+	}
+	w := &stopper.Worker{Stop: s.strategy, Pause: 500 * time.Millisecond}
+	w.Step = func(_ context.Context, i int) error {
+		fmt.Printf("Воркер 1 (%s): обработал число %d\n", spec, i)
+		return nil
+	}
+	if s.input != nil {
+		go func() {
+			for i := 1; ; i++ {
+				select {
+				case s.input <- i:
+				case <-s.closeReq:
+					close(s.input)
+					return
+				}
+			}
+		}()
+		w.Step = stopper.Range(s.input, func(_ context.Context, v int) error {
+			fmt.Printf("Воркер 1 (%s): обработал число %d\n", spec, v)
+			return nil
+		})
+	}
+	if len(s.triggers) > 0 {
+		t := time.AfterFunc(after, func() {
+			for _, trigger := range s.triggers {
+				trigger()
+			}
+		})
+		defer t.Stop()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		returned := false
+		defer func() { // при Goexit Run не возвращается, но отложенные вызовы выполняются
+			if !returned {
+				fmt.Printf("Воркер 1 (%s): горутина завершена через runtime.Goexit\n", spec)
+			}
+		}()
+		err := w.Run(context.Background())
+		returned = true
+		fmt.Printf("Воркер 1 (%s): %v, завершаю работу\n", spec, err)
+	}()
+	wg.Wait()
+	// воркер мог остановиться по другой стратегии раньше, чем сработал close, - тогда генератор
+	// так и ждал бы отправки в канал, который никто не читает; отпускаем его
+	if s.input != nil {
+		s.requestClose()
+	}
+	return nil
+}
+
 func main() {
+	stopFlag := flag.String("stop", "", "вместо семи демонстраций запустить один воркер со стратегиями остановки через запятую: "+
+		"flag, done, ctx, close, timer:D, signal, goexit[:СТРАТЕГИЯ]; срабатывает первая (например ctx,signal,timer:3s)")
+	after := flag.Duration("after", 2*time.Second, "через сколько срабатывают ручные стратегии -stop (flag, done, ctx, close)")
+	flag.Parse()
+
+	if *stopFlag != "" {
+		if err := runStopper(*stopFlag, *after); err != nil {
+			fmt.Println("Ошибка:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var wg sync.WaitGroup
 
 	// демонстрация "выход по условию"
@@ -135,7 +293,7 @@ func main() {
 	time.Sleep(2 * time.Second)
 	stopCondition.Store(true)
 	wg.Wait()
-	fmt.Println("Завершено: Выход по условию\n")
+	fmt.Print("Завершено: Выход по условию\n\n")
 
 	// демонстрация "канал уведомления"
 	fmt.Println("= Канал уведомления =")
@@ -145,7 +303,7 @@ func main() {
 	time.Sleep(2 * time.Second)
 	close(done)
 	wg.Wait()
-	fmt.Println("Завершено: Канал уведомления\n")
+	fmt.Print("Завершено: Канал уведомления\n\n")
 
 	// демонстрация "контекст"
 	fmt.Println("= Контекст =")
@@ -155,7 +313,7 @@ func main() {
 	time.Sleep(2 * time.Second)
 	cancel()
 	wg.Wait()
-	fmt.Println("Завершено: Контекст\n")
+	fmt.Print("Завершено: Контекст\n\n")
 
 	// демонстрация "runtime.Goexit()""
 	fmt.Println("= runtime.Goexit() =")
@@ -166,7 +324,7 @@ func main() {
 	time.Sleep(2 * time.Second)
 	stopGoexit.Store(true)
 	wg.Wait()
-	fmt.Println("Завершено: runtime.Goexit()\n")
+	fmt.Print("Завершено: runtime.Goexit()\n\n")
 
 	// демонстрация "закрытие входного канала"
 	fmt.Println("= Закрытие входного канала =")
@@ -181,14 +339,14 @@ func main() {
 		close(dataCh)
 	}()
 	wg.Wait()
-	fmt.Println("Завершено: Закрытие входного канала\n")
+	fmt.Print("Завершено: Закрытие входного канала\n\n")
 
 	// демонстрация "таймер"
 	fmt.Println("= Таймер =")
 	wg.Add(1)
-	go workerTimer(1, &wg)
+	go workerTimer(1, clock.Real(), &wg) // в main6_test.go тот же воркер проверяется на clock.Fake
 	wg.Wait()
-	fmt.Println("Завершено: Таймер\n")
+	fmt.Print("Завершено: Таймер\n\n")
 
 	// демонстрация "сигналы ОС"
 	fmt.Println("= Сигналы ОС (нажмите Ctrl+C для завершения) =")
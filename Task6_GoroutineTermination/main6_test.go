@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/clock"
+)
+
+// captureStdout подменяет os.Stdout на время f и возвращает всё, что туда напечатано
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	return <-out
+}
+
+// воркер таймера на ручных часах: время двигается на паузу воркера, как только он уснул
+// (ждут двое - таймаут и Sleep), поэтому до таймаута в 2 секунды всегда успевают ровно 4 числа
+func TestWorkerTimerFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	out := captureStdout(t, func() {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go workerTimer(1, clk, &wg)
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-done
+			cancel()
+		}()
+		for clk.BlockUntil(ctx, 2) == nil {
+			clk.Advance(500 * time.Millisecond)
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("воркер не завершился по таймауту")
+		}
+	})
+
+	want := "Воркер 1 (таймер): обработал число 1\n" +
+		"Воркер 1 (таймер): обработал число 2\n" +
+		"Воркер 1 (таймер): обработал число 3\n" +
+		"Воркер 1 (таймер): обработал число 4\n" +
+		"Воркер 1 (таймер): таймаут истек, завершаю работу\n"
+	if out != want {
+		t.Fatalf("вывод воркера:\n%s\nожидалось:\n%s", out, want)
+	}
+	if n := clk.Waiters(); n != 0 {
+		t.Fatalf("после выхода воркера осталось таймеров: %d", n)
+	}
+}
+
+// стратегия close вместе с таймером: воркер останавливается по таймеру, пока ручной триггер close ещё не сработал,
+// и генератор входного канала после этого тоже должен завершиться, а не ждать отправки вечно
+func TestRunStopperReleasesGenerator(t *testing.T) {
+	base := runtime.NumGoroutine()
+	out := captureStdout(t, func() {
+		if err := runStopper("close,timer:50ms", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(out, "таймаут истек") {
+		t.Fatalf("воркер остановился не по таймеру:\n%s", out)
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if left := runtime.NumGoroutine() - base; left > 0 {
+		t.Fatalf("после runStopper осталось лишних горутин: %d", left)
+	}
+}
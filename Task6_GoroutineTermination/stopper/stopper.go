@@ -0,0 +1,169 @@
+// Package stopper - единый воркер для всех способов остановки из решения L1.6.
+// там на каждый способ был свой worker* со своим примитивом в параметрах; здесь воркер один (Worker),
+// а способ остановки - подключаемая стратегия (Strategy). стратегии комбинируются через Any:
+// воркер останавливается по первой сработавшей, и Run возвращает её причину
+package stopper
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/clock"
+)
+
+// причины остановки встроенных стратегий; проверяются через errors.Is
+var (
+	ErrFlag    = errors.New("флаг остановки установлен")
+	ErrDone    = errors.New("получен сигнал остановки через канал")
+	ErrTimeout = errors.New("таймаут истек")
+	ErrClosed  = errors.New("входной канал закрыт")
+)
+
+// Strategy - способ узнать, что воркеру пора остановиться. Watch вызывается один раз при запуске воркера
+// и подключает стратегию через w: асинхронные стратегии (канал, контекст, таймер, сигнал) запускают
+// наблюдение через w.Go и вызывают w.Stop, когда срабатывают; стратегии-условия (флаг) регистрируют
+// проверку через w.Check, и воркер вызывает её перед каждым шагом
+type Strategy interface {
+	Watch(w *Watcher)
+}
+
+// StrategyFunc позволяет использовать обычную функцию как Strategy
+type StrategyFunc func(w *Watcher)
+
+func (f StrategyFunc) Watch(w *Watcher) { f(w) }
+
+// Watcher - то, через что стратегия подключается к запущенному воркеру
+type Watcher struct {
+	run  *runState
+	wrap func(cause error) error // преобразование причины (см. Goexit); nil - без изменений
+}
+
+// runState - общее состояние одного запуска Worker.Run
+type runState struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	clock  clock.Clock
+	wg     sync.WaitGroup
+	checks []func() error
+}
+
+// Context возвращает контекст запуска: он закрывается, когда воркер останавливается по любой причине
+func (w *Watcher) Context() context.Context { return w.run.ctx }
+
+// Clock возвращает часы воркера (Worker.Clock) - по ним должны идти таймеры стратегий
+func (w *Watcher) Clock() clock.Clock { return w.run.clock }
+
+// Stop останавливает воркер с причиной cause; если воркер уже останавливается, вызов ничего не меняет
+func (w *Watcher) Stop(cause error) {
+	w.run.cancel(w.apply(cause))
+}
+
+// Go запускает наблюдение в отдельной горутине. f должна вернуться после закрытия ctx:
+// Run не возвращается, пока не завершатся все наблюдающие горутины
+func (w *Watcher) Go(f func(ctx context.Context)) {
+	w.run.wg.Add(1)
+	go func() {
+		defer w.run.wg.Done()
+		f(w.run.ctx)
+	}()
+}
+
+// Check регистрирует проверку, которую воркер вызывает перед каждым шагом; не nil - причина остановки
+func (w *Watcher) Check(f func() error) {
+	w.run.checks = append(w.run.checks, func() error {
+		if err := f(); err != nil {
+			return w.apply(err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) apply(cause error) error {
+	if w.wrap == nil {
+		return cause
+	}
+	return w.wrap(cause)
+}
+
+// Worker - воркер, который выполняет шаги, пока стратегия Stop (или отмена ctx в Run) не попросит остановиться
+type Worker struct {
+	// Step - один шаг работы, i - номер шага с 1. ошибка останавливает воркер с этой причиной;
+	// долгий шаг должен следить за ctx - он закрывается, когда срабатывает стратегия
+	Step func(ctx context.Context, i int) error
+	// Stop - стратегия остановки; несколько стратегий объединяются через Any. nil - только отмена ctx в Run
+	Stop Strategy
+	// Pause - задержка между шагами; остановка прерывает её сразу, не дожидаясь конца паузы
+	Pause time.Duration
+	// Clock - часы для Pause и таймеров стратегий; nil - clock.Real()
+	Clock clock.Clock
+}
+
+// Run выполняет шаги в текущей горутине и возвращает причину остановки: причину сработавшей стратегии,
+// context.Cause(ctx) при отмене ctx или ошибку шага. к возврату все наблюдающие горутины стратегий завершены.
+// если сработала стратегия, обёрнутая в Goexit, Run не возвращается, а завершает горутину через runtime.Goexit:
+// отложенные вызовы (defer) вызывающей стороны при этом выполняются
+func (w *Worker) Run(ctx context.Context) error {
+	clk := w.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+	run := &runState{clock: clk}
+	run.ctx, run.cancel = context.WithCancelCause(ctx)
+	defer run.wg.Wait()   // выполняется после cancel (defer - в обратном порядке)
+	defer run.cancel(nil) // наблюдающие горутины видят закрытый ctx и завершаются
+	if w.Stop != nil {
+		w.Stop.Watch(&Watcher{run: run})
+	}
+
+	var pause clock.Timer
+	if w.Pause > 0 {
+		pause = clk.NewTimer(w.Pause)
+		pause.Stop()
+		defer pause.Stop()
+	}
+	for i := 1; ; i++ {
+		if err := run.stopped(); err != nil {
+			return exit(err)
+		}
+		if err := w.Step(run.ctx, i); err != nil {
+			if run.ctx.Err() != nil { // шаг прервался из-за остановки - важнее её причина
+				err = context.Cause(run.ctx)
+			}
+			return exit(err)
+		}
+		if pause != nil {
+			pause.Reset(w.Pause) // таймер остановлен или уже сработал и вычитан - канал пуст
+			select {
+			case <-pause.C():
+			case <-run.ctx.Done():
+				pause.Stop()
+			}
+		}
+	}
+}
+
+// stopped возвращает причину остановки, если воркер уже остановлен или одна из проверок сработала
+func (r *runState) stopped() error {
+	if r.ctx.Err() != nil {
+		return context.Cause(r.ctx)
+	}
+	for _, check := range r.checks {
+		if err := check(); err != nil {
+			r.cancel(err) // остальные стратегии тоже видят остановку
+			return context.Cause(r.ctx)
+		}
+	}
+	return nil
+}
+
+// exit возвращает причину остановки или завершает горутину, если причина помечена Goexit
+func exit(cause error) error {
+	var g *GoexitError
+	if errors.As(cause, &g) {
+		runtime.Goexit()
+	}
+	return cause
+}
@@ -0,0 +1,207 @@
+package stopper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task6_GoroutineTermination/clock"
+)
+
+// countSteps - шаг, который только считает вызовы
+func countSteps(n *atomic.Int64) func(context.Context, int) error {
+	return func(context.Context, int) error {
+		n.Add(1)
+		return nil
+	}
+}
+
+// Any останавливает воркер по первой сработавшей стратегии, и её причину не перебивают следующие
+func TestAnyFirstWins(t *testing.T) {
+	var flag atomic.Bool
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	var steps atomic.Int64
+	w := &Worker{
+		Stop: Any(Flag(&flag), Done(done), Context(ctx)),
+		Step: func(stepCtx context.Context, i int) error {
+			steps.Add(1)
+			if i == 3 {
+				close(done)
+				<-stepCtx.Done() // Done сработал - остальные стратегии срабатывают уже после него
+				flag.Store(true)
+				cancel(errors.New("поздняя отмена"))
+			}
+			return nil
+		},
+	}
+	if err := w.Run(context.Background()); !errors.Is(err, ErrDone) {
+		t.Fatalf("Run вернул %v, ожидалось ErrDone", err)
+	}
+	if n := steps.Load(); n != 3 {
+		t.Fatalf("выполнено %d шагов, ожидалось 3", n)
+	}
+
+	// флаг уже установлен - воркер не делает ни одного шага
+	steps.Store(0)
+	w = &Worker{Stop: Any(Done(make(chan struct{})), Flag(&flag)), Step: countSteps(&steps)}
+	if err := w.Run(context.Background()); !errors.Is(err, ErrFlag) || steps.Load() != 0 {
+		t.Fatalf("Run вернул %v после %d шагов, ожидалось ErrFlag без шагов", err, steps.Load())
+	}
+
+	// без стратегий воркер останавливает только отмена ctx в Run, с её причиной
+	cause := errors.New("отмена снаружи")
+	runCtx, runCancel := context.WithCancelCause(context.Background())
+	w = &Worker{Step: func(_ context.Context, i int) error {
+		if i == 2 {
+			runCancel(cause)
+		}
+		return nil
+	}}
+	if err := w.Run(runCtx); !errors.Is(err, cause) {
+		t.Fatalf("Run вернул %v, ожидалась причина отмены ctx", err)
+	}
+}
+
+// Goexit: Run не возвращается, горутина завершается через runtime.Goexit, а её defer выполняются
+func TestGoexit(t *testing.T) {
+	var flag atomic.Bool
+	flag.Store(true)
+	returned := make(chan error, 1)
+	deferred := make(chan struct{})
+	go func() {
+		defer close(deferred)
+		err := (&Worker{Stop: Goexit(Flag(&flag)), Step: func(context.Context, int) error { return nil }}).Run(context.Background())
+		returned <- err
+	}()
+	select {
+	case <-deferred:
+	case <-time.After(5 * time.Second):
+		t.Fatal("горутина воркера не завершилась")
+	}
+	select {
+	case err := <-returned:
+		t.Fatalf("Run вернулся с %v вместо runtime.Goexit", err)
+	default:
+	}
+
+	// Goexit действует только на обёрнутую стратегию: остановка по другой возвращается из Run как обычно
+	done := make(chan struct{})
+	close(done)
+	var never atomic.Bool
+	w := &Worker{Stop: Any(Goexit(Flag(&never)), Done(done)), Step: func(ctx context.Context, _ int) error {
+		<-ctx.Done()
+		return nil
+	}}
+	err := w.Run(context.Background())
+	var g *GoexitError
+	if !errors.Is(err, ErrDone) || errors.As(err, &g) {
+		t.Fatalf("Run вернул %v, ожидалось ErrDone без GoexitError", err)
+	}
+}
+
+// Range обрабатывает значения по порядку и останавливает воркер с ErrClosed, когда канал закрыт;
+// ожидание значения прерывается другой стратегией
+func TestRange(t *testing.T) {
+	ch := make(chan int)
+	go func() {
+		for i := 1; i <= 5; i++ {
+			ch <- i * 10
+		}
+		close(ch)
+	}()
+	var got []int
+	w := &Worker{Step: Range(ch, func(_ context.Context, v int) error {
+		got = append(got, v)
+		return nil
+	})}
+	if err := w.Run(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Run вернул %v, ожидалось ErrClosed", err)
+	}
+	if want := []int{10, 20, 30, 40, 50}; !slices.Equal(got, want) {
+		t.Fatalf("обработано %v, ожидалось %v", got, want)
+	}
+
+	// в канал никто не пишет - воркер останавливает Done, пока Range ждёт значение
+	done := make(chan struct{})
+	time.AfterFunc(10*time.Millisecond, func() { close(done) })
+	w = &Worker{Stop: Done(done), Step: Range(make(chan int), func(context.Context, int) error { return nil })}
+	if err := w.Run(context.Background()); !errors.Is(err, ErrDone) {
+		t.Fatalf("Run вернул %v, ожидалось ErrDone", err)
+	}
+
+	// ошибка обработки значения останавливает воркер с этой ошибкой
+	bad := errors.New("плохое значение")
+	ch = make(chan int, 1)
+	ch <- 1
+	w = &Worker{Step: Range(ch, func(context.Context, int) error { return bad })}
+	if err := w.Run(context.Background()); !errors.Is(err, bad) {
+		t.Fatalf("Run вернул %v, ожидалась ошибка обработки", err)
+	}
+}
+
+// к возврату Run все горутины, запущенные стратегиями через Watcher.Go, уже завершились
+func TestWatcherGoroutinesDone(t *testing.T) {
+	var running, exited atomic.Int64
+	slow := StrategyFunc(func(w *Watcher) {
+		for i := 0; i < 3; i++ {
+			w.Go(func(ctx context.Context) {
+				running.Add(1)
+				<-ctx.Done()
+				time.Sleep(10 * time.Millisecond) // медленная уборка - Run всё равно её дождётся
+				exited.Add(1)
+			})
+		}
+	})
+	w := &Worker{Stop: Any(slow, Flag(new(atomic.Bool))), Step: func(_ context.Context, i int) error {
+		if i == 2 {
+			return errors.New("шаг не удался")
+		}
+		return nil
+	}}
+	if err := w.Run(context.Background()); err == nil || err.Error() != "шаг не удался" {
+		t.Fatalf("Run вернул %v, ожидалась ошибка шага", err)
+	}
+	if e := exited.Load(); e != 3 {
+		t.Fatalf("к возврату Run завершились %d из 3 наблюдающих горутин (запущено %d)", e, running.Load())
+	}
+}
+
+// Timer идёт по часам Worker.Clock: на ручных часах таймаут 1.9s при паузе 0.5s всегда даёт ровно 4 шага,
+// а пауза прерывается остановкой, не дожидаясь своего конца
+func TestTimerFakeClock(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var steps atomic.Int64
+	w := &Worker{Stop: Timer(1900 * time.Millisecond), Pause: 500 * time.Millisecond, Clock: clk, Step: countSteps(&steps)}
+	result := make(chan error, 1)
+	go func() { result <- w.Run(context.Background()) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, d := range []time.Duration{500, 500, 500, 400} {
+		// ждут двое: таймер стратегии и пауза воркера - значит, шаг выполнен и воркер уснул
+		if err := clk.BlockUntil(ctx, 2); err != nil {
+			t.Fatalf("воркер не дошёл до паузы после %d шагов: %v", steps.Load(), err)
+		}
+		clk.Advance(d * time.Millisecond)
+	}
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrTimeout) {
+			t.Fatalf("Run вернул %v, ожидалось ErrTimeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("воркер не остановился по таймеру")
+	}
+	if n := steps.Load(); n != 4 {
+		t.Fatalf("выполнено %d шагов, ожидалось 4", n)
+	}
+	if n := clk.Waiters(); n != 0 {
+		t.Fatalf("после остановки осталось таймеров: %d", n)
+	}
+}
@@ -0,0 +1,140 @@
+package stopper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"time"
+)
+
+// Flag - выход по условию: воркер проверяет флаг перед каждым шагом (как workerCondition).
+// отдельной горутины нет, поэтому остановка замечается только между шагами
+func Flag(stop *atomic.Bool) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		w.Check(func() error {
+			if stop.Load() {
+				return ErrFlag
+			}
+			return nil
+		})
+	})
+}
+
+// Done - канал уведомления: воркер останавливается, когда done закрывается или из него приходит значение
+func Done(done <-chan struct{}) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		w.Go(func(ctx context.Context) {
+			select {
+			case <-done:
+				w.Stop(ErrDone)
+			case <-ctx.Done():
+			}
+		})
+	})
+}
+
+// Context - остановка по отмене ctx с его причиной (context.Cause). Run и так следит за своим ctx;
+// эта стратегия нужна, чтобы добавить к воркеру ещё один контекст, например от другой подсистемы
+func Context(ctx context.Context) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		w.Go(func(runCtx context.Context) {
+			select {
+			case <-ctx.Done():
+				w.Stop(context.Cause(ctx))
+			case <-runCtx.Done():
+			}
+		})
+	})
+}
+
+// Timer - остановка через d после запуска воркера по часам Worker.Clock (как workerTimer)
+func Timer(d time.Duration) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		timer := w.Clock().NewTimer(d)
+		w.Go(func(ctx context.Context) {
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+				w.Stop(ErrTimeout)
+			case <-ctx.Done():
+			}
+		})
+	})
+}
+
+// SignalError - причина остановки по сигналу ОС
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("получен сигнал %v", e.Signal)
+}
+
+// Signal - остановка по сигналу ОС (как workerSignal). сигналы перехватываются только пока воркер работает:
+// после остановки signal.Stop возвращает им обычное поведение (например, завершение программы по Ctrl+C)
+func Signal(sigs ...os.Signal) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, sigs...)
+		w.Go(func(ctx context.Context) {
+			defer signal.Stop(sigCh)
+			select {
+			case sig := <-sigCh:
+				w.Stop(&SignalError{Signal: sig})
+			case <-ctx.Done():
+			}
+		})
+	})
+}
+
+// GoexitError - причина остановки стратегии, обёрнутой в Goexit; Cause - исходная причина
+type GoexitError struct {
+	Cause error
+}
+
+func (e *GoexitError) Error() string {
+	return "runtime.Goexit: " + e.Cause.Error()
+}
+
+func (e *GoexitError) Unwrap() error { return e.Cause }
+
+// Goexit меняет способ выхода: когда срабатывает s, воркер завершает свою горутину через runtime.Goexit
+// (как workerGoexit), а не возвращается из Run. отложенные вызовы горутины при этом выполняются,
+// поэтому сигнал о завершении (wg.Done, закрытие канала) нужно отправлять из defer
+func Goexit(s Strategy) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		s.Watch(&Watcher{run: w.run, wrap: func(cause error) error {
+			return w.apply(&GoexitError{Cause: cause})
+		}})
+	})
+}
+
+// Any объединяет стратегии: воркер останавливается по первой сработавшей, остальные больше не срабатывают
+func Any(ss ...Strategy) Strategy {
+	return StrategyFunc(func(w *Watcher) {
+		for _, s := range ss {
+			s.Watch(w)
+		}
+	})
+}
+
+// Range - шаг воркера, который обрабатывает значения из ch функцией f, пока ch не закроют
+// (как workerChannelClose). закрытие входного канала - не внешняя стратегия, а конец данных:
+// шаг возвращает ErrClosed, и воркер останавливается с этой причиной. ожидание значения прерывается
+// остановкой по любой другой стратегии, поэтому Range комбинируется с ними как обычно
+func Range[T any](ch <-chan T, f func(ctx context.Context, v T) error) func(ctx context.Context, i int) error {
+	return func(ctx context.Context, _ int) error {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return ErrClosed
+			}
+			return f(ctx, v)
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
@@ -1,35 +1,266 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-// generateNumbers отправляет числа из массива в канал
-func generateNumbers(nums []int, chOut chan<- int) {
-	for _, n := range nums {
-		chOut <- n
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/numeric"
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/pipeline"
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/spec"
+)
+
+// multiplyByTwo возвращает этап обработки, умножающий число на 2 в арифметике a.
+// в режиме int переполнение - ошибка этапа (numeric.ErrOverflow), а не тихо завёрнутый результат
+func multiplyByTwo[T any](a numeric.Arith[T]) func(ctx context.Context, num T) (T, error) {
+	two := a.FromInt(2)
+	return func(_ context.Context, num T) (T, error) {
+		return a.Mul(num, two)
 	}
-	close(chOut) // закрываем канал после отправки
 }
 
-// multiplyByTwo читает числа из канала, умножает на 2 и отправляет в другой канал
-func multiplyByTwo(chIn <-chan int, chOut chan<- int) {
-	for num := range chIn {
-		chOut <- num * 2
+func main() {
+	diag := flag.Bool("diag", false, "вывести в stderr статистику этапов и узкое место конвейера")
+	inputFlag := flag.String("input", "", "откуда читать числа: файл, шаблон файлов (data/*.txt.gz) или - для stdin; по умолчанию числа 1..5")
+	sepFlag := flag.String("sep", "newline", "разделитель чисел во входных данных: newline, comma или space")
+	modeFlag := flag.String("mode", "int", "представление чисел: "+numeric.Modes+" (bigint, rat и float - math/big, без переполнения)")
+	precFlag := flag.Uint("prec", numeric.DefaultPrec, "точность режима float, бит мантиссы")
+	windowFlag := flag.String("window", "", "вместо чисел выводить агрегаты по окнам: count:N, count:N/STEP, time:1s, time:5s/1s или session:500ms")
+	checkpointFlag := flag.String("checkpoint", "", "файл контрольной точки: прерванный запуск с тем же -input продолжится после последнего обработанного числа (только с файлами в -input, не со stdin, и без -window)")
+	everyFlag := flag.Duration("checkpoint-every", time.Second, "как часто сохранять контрольную точку")
+	configFlag := flag.String("config", "", "описание конвейера в YAML или JSON (источник, этапы, приёмник); -input, -sep, -mode и -prec при этом не используются")
+	flag.Parse()
+
+	if *configFlag != "" {
+		runConfig(*configFlag, *diag)
+		return
+	}
+
+	sep, err := pipeline.ParseSeparator(*sepFlag)
+	if err != nil {
+		fmt.Println("Ошибка:", err)
+		os.Exit(1)
+	}
+	in := pipeline.Input{Paths: []string{*inputFlag}, Sep: sep}
+	if *inputFlag == "" {
+		in.Paths = nil
+	}
+	var win *window
+	if *windowFlag != "" {
+		if win, err = parseWindow(*windowFlag); err != nil {
+			fmt.Println("Ошибка:", err)
+			os.Exit(1)
+		}
+	}
+
+	var ck *pipeline.Checkpointer
+	if *checkpointFlag != "" {
+		if len(in.Paths) == 0 || *inputFlag == "-" || win != nil {
+			fmt.Println("Ошибка: -checkpoint работает только с файлами в -input (не со stdin) и без -window")
+			os.Exit(1)
+		}
+		if ck, err = pipeline.OpenCheckpointer(*checkpointFlag, in, *everyFlag); err != nil {
+			fmt.Println("Ошибка:", err)
+			os.Exit(1)
+		}
+		if ck.Done() {
+			fmt.Fprintf(os.Stderr, "вход уже обработан полностью (%d чисел, контрольная точка %s); удалите её, чтобы начать заново\n",
+				ck.Items(), *checkpointFlag)
+			return
+		}
+		if in.Resume = ck.Resume(); in.Resume != nil {
+			fmt.Fprintf(os.Stderr, "продолжаем с %s:%d (смещение %d), уже обработано %d чисел\n",
+				in.Resume.File, in.Resume.Line, in.Resume.Offset, ck.Items())
+		}
+	}
+
+	p := pipeline.New(context.Background())
+	stopOnSignal(p)
+
+	// конвейер собирается отдельно для каждого представления чисел: этапы обобщённые,
+	// поэтому тип значений в каналах (int, *big.Int, ...) известен на этапе компиляции
+	switch *modeFlag {
+	case "int":
+		err = run(p, numeric.Int{}, in, win, ck)
+	case "bigint":
+		err = run(p, numeric.BigInt{}, in, win, ck)
+	case "rat":
+		err = run(p, numeric.Rat{}, in, win, ck)
+	case "float":
+		if *precFlag == 0 {
+			fmt.Println("Ошибка: -prec должен быть больше 0")
+			os.Exit(1)
+		}
+		err = run(p, numeric.Float{Prec: *precFlag}, in, win, ck)
+	default:
+		fmt.Printf("Ошибка: неизвестный режим %q (допустимо: %s)\n", *modeFlag, numeric.Modes)
+		os.Exit(1)
+	}
+	if ck != nil {
+		// вход обработан до конца, только если конвейер не упал и не был остановлен по Ctrl+C
+		complete := err == nil && !p.Interrupted()
+		if cerr := ck.Close(complete); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	if *diag {
+		p.Diagnose(os.Stderr)
+	}
+	if err != nil {
+		fmt.Println("Ошибка конвейера:", err)
+		os.Exit(1)
 	}
-	close(chOut) // закрываем канал после обработки
 }
 
-func main() {
-	numbers := []int{1, 2, 3, 4, 5}
+// stopOnSignal: первый Ctrl+C (SIGINT/SIGTERM) мягко останавливает конвейер - источник перестаёт читать,
+// уже прочитанные числа дообрабатываются, незавершённые окна выводятся; повторный Ctrl+C - немедленная отмена
+func stopOnSignal(p *pipeline.Pipeline) {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		p.Stop()
+		<-sigCh
+		p.Cancel()
+	}()
+}
 
-	input := make(chan int)
-	output := make(chan int)
+// window - окно из флага -window
+type window struct {
+	kind       string // count, time или session
+	size, step int
+	dur, every time.Duration
+}
 
-	go generateNumbers(numbers, input)
-	go multiplyByTwo(input, output)
+// parseWindow разбирает описание окна: count:N[/STEP], time:D[/STEP] или session:GAP
+func parseWindow(s string) (*window, error) {
+	kind, arg, _ := strings.Cut(s, ":")
+	sizeStr, stepStr, sliding := strings.Cut(arg, "/")
+	w := &window{kind: kind}
+	var err error
+	switch kind {
+	case "count":
+		if w.size, err = strconv.Atoi(sizeStr); err != nil || w.size <= 0 {
+			return nil, fmt.Errorf("окно %q: размер должен быть целым больше 0", s)
+		}
+		w.step = w.size
+		if sliding {
+			if w.step, err = strconv.Atoi(stepStr); err != nil || w.step <= 0 {
+				return nil, fmt.Errorf("окно %q: шаг должен быть целым больше 0", s)
+			}
+		}
+	case "time", "session":
+		if w.dur, err = time.ParseDuration(sizeStr); err != nil || w.dur <= 0 {
+			return nil, fmt.Errorf("окно %q: ожидается положительная длительность, например 1s или 500ms", s)
+		}
+		w.every = w.dur
+		if sliding && kind == "session" {
+			return nil, fmt.Errorf("окно %q: у сессии нет шага", s)
+		}
+		if sliding {
+			if w.every, err = time.ParseDuration(stepStr); err != nil || w.every <= 0 {
+				return nil, fmt.Errorf("окно %q: шаг должен быть положительной длительностью", s)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("окно %q: ожидается count:N[/STEP], time:D[/STEP] или session:GAP", s)
+	}
+	return w, nil
+}
+
+// printWindows добавляет к потоку оконный этап и агрегацию и выводит агрегаты по одной строке на окно
+func printWindows[T any](s pipeline.Stream[T], a numeric.Arith[T], w *window) error {
+	var windows pipeline.Stream[pipeline.Window[T]]
+	switch w.kind {
+	case "count":
+		windows = pipeline.SlidingCount(s, w.size, w.step, pipeline.Name("window"))
+	case "time":
+		windows = pipeline.SlidingTime(s, w.dur, w.every, pipeline.Name("window"))
+	default:
+		windows = pipeline.Session(s, w.dur, pipeline.Name("window"))
+	}
+	return pipeline.Aggregate(windows, a).ForEach(func(sum pipeline.Summary[T]) error {
+		partial := ""
+		if sum.Partial {
+			partial = " (неполное)"
+		}
+		_, err := fmt.Printf("окно %s-%s%s: count=%d sum=%s min=%s max=%s mean=%s\n",
+			sum.Start.Format("15:04:05.000"), sum.End.Format("15:04:05.000"), partial,
+			sum.Count, a.String(sum.Sum), a.String(sum.Min), a.String(sum.Max), a.String(sum.Mean))
+		return err
+	}, pipeline.Name("print"))
+}
+
+// runCheckpointed - run с контрольными точками: числа идут по конвейеру вместе с местом во входе,
+// и приёмник подтверждает каждое выведенное число. этап умножения сохраняет порядок (Ordered),
+// поэтому подтверждённое место - граница, до которой вход обработан полностью
+func runCheckpointed[T any](p *pipeline.Pipeline, a numeric.Arith[T], in pipeline.Input, ck *pipeline.Checkpointer) error {
+	input := pipeline.NumbersAt(p, in, a.Parse)
+	output := pipeline.MapRecord(input, multiplyByTwo(a), pipeline.Workers(3), pipeline.Ordered(), pipeline.Name("multiply"))
+	return output.ForEach(func(r pipeline.Record[T]) error {
+		if _, err := fmt.Println(a.String(r.Value)); err != nil {
+			return err
+		}
+		ck.Ack(r.Pos)
+		return nil
+	}, pipeline.Name("print"))
+}
+
+// run собирает и выполняет конвейер в арифметике a; без входных файлов обрабатываются числа 1..5.
+// если задано окно win, вместо самих чисел выводятся агрегаты по окнам; если задана контрольная точка ck - см. runCheckpointed
+func run[T any](p *pipeline.Pipeline, a numeric.Arith[T], in pipeline.Input, win *window, ck *pipeline.Checkpointer) error {
+	if ck != nil {
+		return runCheckpointed(p, a, in, ck)
+	}
 
-	// читаем результаты из output и выводим
-	for result := range output {
-		fmt.Println(result)
+	// конвейер из двух этапов: генерация чисел (FromSlice) и их обработка (MapErr);
+	// каналы между этапами создаются и закрываются внутри пакета pipeline
+	var input pipeline.Stream[T]
+	if len(in.Paths) > 0 {
+		// числа читаются и разбираются потоково, поэтому размер входа не ограничен памятью
+		input = pipeline.NumbersOf(p, in, a.Parse)
+	} else {
+		numbers := []T{a.FromInt(1), a.FromInt(2), a.FromInt(3), a.FromInt(4), a.FromInt(5)}
+		input = pipeline.FromSlice(p, numbers, pipeline.Name("generate"))
+	}
+	// этап умножения выполняется в 3 параллельных копиях (fan-out/fan-in) с сохранением порядка чисел
+	output := pipeline.MapErr(input, multiplyByTwo(a), pipeline.Workers(3), pipeline.Ordered(), pipeline.Name("multiply"))
+
+	if win != nil {
+		return printWindows(output, a, win)
+	}
+
+	// читаем результаты из output и выводим; ForEach ждёт завершения всех этапов и возвращает ошибку конвейера
+	return output.ForEach(func(result T) error {
+		_, err := fmt.Println(a.String(result))
+		return err
+	}, pipeline.Name("print"))
+}
+
+// runConfig собирает конвейер по файлу описания и запускает его;
+// ошибки конфигурации выводятся все сразу, каждая со строкой файла
+func runConfig(path string, diag bool) {
+	s, err := spec.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Ошибка конфигурации:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	p := pipeline.New(context.Background())
+	stopOnSignal(p) // как и без -config: Ctrl+C останавливает источник, и Spec.Run успевает сбросить буфер вывода
+	err = s.Run(p, os.Stdout)
+	if diag {
+		p.Diagnose(os.Stderr)
+	}
+	if err != nil {
+		fmt.Println("Ошибка конвейера:", err)
+		os.Exit(1)
 	}
 }
 
@@ -0,0 +1,41 @@
+package numeric
+
+// Erase скрывает тип значений арифметики за any. значения, переданные в методы результата,
+// должны быть получены от него же (Parse, FromInt, операции), иначе приведение типа запаникует
+func Erase[T any](a Arith[T]) Arith[any] {
+	return erased[T]{a}
+}
+
+type erased[T any] struct {
+	a Arith[T]
+}
+
+func (e erased[T]) Name() string  { return e.a.Name() }
+func (e erased[T]) Integer() bool { return e.a.Integer() }
+
+func (e erased[T]) Parse(s string) (any, error) {
+	v, err := e.a.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (e erased[T]) FromInt(n int64) any { return e.a.FromInt(n) }
+
+// op применяет типизированную операцию к значениям any
+func op[T any](f func(a, b T) (T, error), a, b any) (any, error) {
+	v, err := f(a.(T), b.(T))
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (e erased[T]) Add(a, b any) (any, error) { return op(e.a.Add, a, b) }
+func (e erased[T]) Sub(a, b any) (any, error) { return op(e.a.Sub, a, b) }
+func (e erased[T]) Mul(a, b any) (any, error) { return op(e.a.Mul, a, b) }
+func (e erased[T]) Quo(a, b any) (any, error) { return op(e.a.Quo, a, b) }
+func (e erased[T]) Rem(a, b any) (any, error) { return op(e.a.Rem, a, b) }
+func (e erased[T]) Cmp(a, b any) int          { return e.a.Cmp(a.(T), b.(T)) }
+func (e erased[T]) String(v any) string       { return e.a.String(v.(T)) }
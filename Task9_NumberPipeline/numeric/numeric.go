@@ -0,0 +1,235 @@
+// Package numeric - арифметика для этапов конвейера (L1.9) с выбором представления чисел:
+// int с проверкой переполнения или произвольная точность из math/big (*big.Int, *big.Rat, *big.Float).
+// этапы пишутся один раз через интерфейс Arith и работают в любом режиме
+package numeric
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/Kras0Tanya/WB-L1/internal/checked"
+)
+
+var (
+	// ErrOverflow - результат не помещается в int; в режиме int вместо тихого переполнения возвращается эта ошибка
+	ErrOverflow = errors.New("переполнение int (используйте режим bigint, rat или float)")
+	// ErrDivByZero - деление на ноль
+	ErrDivByZero = errors.New("деление на ноль")
+	// ErrNotInteger - операция определена только для целых режимов (int, bigint)
+	ErrNotInteger = errors.New("операция определена только для целых чисел")
+	// ErrInfinite - бесконечность в режиме float: на входе или как результат выхода за диапазон экспоненты big.Float
+	ErrInfinite = errors.New("бесконечность не поддерживается")
+)
+
+// Arith - набор операций над числами типа T. результаты всегда новые значения:
+// аргументы не изменяются, поэтому одно и то же значение можно безопасно передавать между горутинами
+type Arith[T any] interface {
+	Name() string
+	Integer() bool // true для целых режимов: Rem определён, Quo отбрасывает дробную часть
+	Parse(s string) (T, error)
+	FromInt(n int64) T
+	Add(a, b T) (T, error)
+	Sub(a, b T) (T, error)
+	Mul(a, b T) (T, error)
+	Quo(a, b T) (T, error)
+	Rem(a, b T) (T, error)
+	Cmp(a, b T) int
+	String(v T) string
+}
+
+// DefaultPrec - точность *big.Float по умолчанию, бит мантиссы (около 77 десятичных знаков)
+const DefaultPrec = 256
+
+// Modes - допустимые названия режимов для флагов и конфигурации
+const Modes = "int, bigint, rat или float"
+
+// ByName возвращает арифметику режима mode со стёртым типом (значения - any);
+// нужна там, где режим известен только во время выполнения, например при разборе конфигурации.
+// prec учитывается только в режиме float (0 - DefaultPrec)
+func ByName(mode string, prec uint) (Arith[any], error) {
+	switch mode {
+	case "int", "":
+		return Erase[int](Int{}), nil
+	case "bigint":
+		return Erase[*big.Int](BigInt{}), nil
+	case "rat":
+		return Erase[*big.Rat](Rat{}), nil
+	case "float":
+		return Erase[*big.Float](Float{Prec: prec}), nil
+	}
+	return nil, fmt.Errorf("неизвестный режим %q (допустимо: %s)", mode, Modes)
+}
+
+// Int - машинный int с проверкой переполнения: вместо заворачивания результата - ErrOverflow
+type Int struct{}
+
+func (Int) Name() string  { return "int" }
+func (Int) Integer() bool { return true }
+
+func (Int) Parse(s string) (int, error) { return strconv.Atoi(s) }
+func (Int) FromInt(n int64) int         { return int(n) }
+
+func (Int) Add(a, b int) (int, error) { return overflow(checked.Add(a, b)) }
+func (Int) Sub(a, b int) (int, error) { return overflow(checked.Sub(a, b)) }
+func (Int) Mul(a, b int) (int, error) { return overflow(checked.Mul(a, b)) }
+
+// overflow заменяет checked.ErrOverflow на ErrOverflow с подсказкой о режимах math/big
+func overflow(v int, err error) (int, error) {
+	if err != nil {
+		return 0, ErrOverflow
+	}
+	return v, nil
+}
+
+func (Int) Quo(a, b int) (int, error) {
+	switch {
+	case b == 0:
+		return 0, ErrDivByZero
+	case a == math.MinInt && b == -1:
+		return 0, ErrOverflow
+	}
+	return a / b, nil
+}
+
+func (Int) Rem(a, b int) (int, error) {
+	if b == 0 {
+		return 0, ErrDivByZero
+	}
+	if b == -1 { // MinInt % -1 паникует на некоторых платформах, а результат всегда 0
+		return 0, nil
+	}
+	return a % b, nil
+}
+
+func (Int) Cmp(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (Int) String(v int) string { return strconv.Itoa(v) }
+
+// BigInt - целые произвольной длины; Quo и Rem - с усечением к нулю, как у int
+type BigInt struct{}
+
+func (BigInt) Name() string  { return "bigint" }
+func (BigInt) Integer() bool { return true }
+
+func (BigInt) Parse(s string) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, strconv.ErrSyntax
+	}
+	return v, nil
+}
+
+func (BigInt) FromInt(n int64) *big.Int            { return big.NewInt(n) }
+func (BigInt) Add(a, b *big.Int) (*big.Int, error) { return new(big.Int).Add(a, b), nil }
+func (BigInt) Sub(a, b *big.Int) (*big.Int, error) { return new(big.Int).Sub(a, b), nil }
+func (BigInt) Mul(a, b *big.Int) (*big.Int, error) { return new(big.Int).Mul(a, b), nil }
+func (BigInt) Cmp(a, b *big.Int) int               { return a.Cmp(b) }
+func (BigInt) String(v *big.Int) string            { return v.String() }
+
+func (BigInt) Quo(a, b *big.Int) (*big.Int, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivByZero
+	}
+	return new(big.Int).Quo(a, b), nil
+}
+
+func (BigInt) Rem(a, b *big.Int) (*big.Int, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivByZero
+	}
+	return new(big.Int).Rem(a, b), nil
+}
+
+// Rat - точные дроби: подходит для денежных расчётов, где недопустимо округление.
+// Parse принимает "12", "-3/4" и десятичную запись "19.99"
+type Rat struct{}
+
+func (Rat) Name() string  { return "rat" }
+func (Rat) Integer() bool { return false }
+
+func (Rat) Parse(s string) (*big.Rat, error) {
+	v, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, strconv.ErrSyntax
+	}
+	return v, nil
+}
+
+func (Rat) FromInt(n int64) *big.Rat            { return new(big.Rat).SetInt64(n) }
+func (Rat) Add(a, b *big.Rat) (*big.Rat, error) { return new(big.Rat).Add(a, b), nil }
+func (Rat) Sub(a, b *big.Rat) (*big.Rat, error) { return new(big.Rat).Sub(a, b), nil }
+func (Rat) Mul(a, b *big.Rat) (*big.Rat, error) { return new(big.Rat).Mul(a, b), nil }
+func (Rat) Rem(_, _ *big.Rat) (*big.Rat, error) { return nil, ErrNotInteger }
+func (Rat) Cmp(a, b *big.Rat) int               { return a.Cmp(b) }
+
+func (Rat) Quo(a, b *big.Rat) (*big.Rat, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivByZero
+	}
+	return new(big.Rat).Quo(a, b), nil
+}
+
+// String выводит целые без знаменателя, остальные - как дробь "a/b"
+func (Rat) String(v *big.Rat) string { return v.RatString() }
+
+// Float - двоичная плавающая точка с точностью Prec бит мантиссы (0 - DefaultPrec).
+// в отличие от float64 точность задаётся, но десятичные дроби вроде 0.1 всё равно хранятся приближённо
+type Float struct {
+	Prec uint
+}
+
+func (f Float) prec() uint {
+	if f.Prec == 0 {
+		return DefaultPrec
+	}
+	return f.Prec
+}
+
+func (f Float) new() *big.Float { return new(big.Float).SetPrec(f.prec()) }
+
+func (Float) Name() string  { return "float" }
+func (Float) Integer() bool { return false }
+
+func (f Float) Parse(s string) (*big.Float, error) {
+	v, _, err := big.ParseFloat(s, 10, f.prec(), big.ToNearestEven)
+	if err != nil {
+		return nil, strconv.ErrSyntax
+	}
+	// ParseFloat принимает Inf, а арифметика big.Float паникует на Inf-Inf, 0*Inf и Inf/Inf (big.ErrNaN)
+	return finite(v)
+}
+
+// finite возвращает ErrInfinite вместо бесконечного значения: так бесконечность не попадает
+// в следующие операции, где big.Float запаниковал бы
+func finite(v *big.Float) (*big.Float, error) {
+	if v.IsInf() {
+		return nil, ErrInfinite
+	}
+	return v, nil
+}
+
+func (f Float) FromInt(n int64) *big.Float              { return f.new().SetInt64(n) }
+func (f Float) Add(a, b *big.Float) (*big.Float, error) { return finite(f.new().Add(a, b)) }
+func (f Float) Sub(a, b *big.Float) (*big.Float, error) { return finite(f.new().Sub(a, b)) }
+func (f Float) Mul(a, b *big.Float) (*big.Float, error) { return finite(f.new().Mul(a, b)) }
+func (Float) Rem(_, _ *big.Float) (*big.Float, error)   { return nil, ErrNotInteger }
+func (Float) Cmp(a, b *big.Float) int                   { return a.Cmp(b) }
+func (Float) String(v *big.Float) string                { return v.Text('g', -1) }
+
+func (f Float) Quo(a, b *big.Float) (*big.Float, error) {
+	if b.Sign() == 0 {
+		return nil, ErrDivByZero
+	}
+	return finite(f.new().Quo(a, b))
+}
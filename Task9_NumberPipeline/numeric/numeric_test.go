@@ -0,0 +1,102 @@
+package numeric
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+// бесконечность отвергается при разборе, поэтому не доходит до операций, на которых big.Float паникует
+func TestFloatRejectsInfinity(t *testing.T) {
+	f := Float{}
+	for _, s := range []string{"Inf", "-Inf", "+inf"} {
+		if _, err := f.Parse(s); !errors.Is(err, ErrInfinite) {
+			t.Errorf("Parse(%q): ошибка %v, ожидалась ErrInfinite", s, err)
+		}
+	}
+	if _, err := f.Parse("1e400"); err != nil {
+		t.Errorf("Parse(1e400): %v - big.Float хранит такие числа без переполнения", err)
+	}
+}
+
+// выход за диапазон экспоненты big.Float даёт ErrInfinite, а не бесконечность в следующих операциях
+func TestFloatExponentOverflow(t *testing.T) {
+	f := Float{Prec: 64}
+	huge := new(big.Float).SetMantExp(big.NewFloat(0.5), math.MaxInt32)
+	if _, err := f.Mul(huge, f.FromInt(4)); !errors.Is(err, ErrInfinite) {
+		t.Fatalf("Mul: ошибка %v, ожидалась ErrInfinite", err)
+	}
+	if _, err := f.Add(huge, huge); !errors.Is(err, ErrInfinite) {
+		t.Fatalf("Add: ошибка %v, ожидалась ErrInfinite", err)
+	}
+	tiny := new(big.Float).SetMantExp(big.NewFloat(0.5), math.MinInt32+1)
+	if _, err := f.Quo(huge, tiny); !errors.Is(err, ErrInfinite) {
+		t.Fatalf("Quo: ошибка %v, ожидалась ErrInfinite", err)
+	}
+}
+
+// одни и те же операции во всех режимах через стёртый тип Arith[any]
+func TestModes(t *testing.T) {
+	cases := []struct {
+		mode, a, b           string
+		sum, diff, prod, quo string
+		quoErr               error
+	}{
+		{"int", "7", "2", "9", "5", "14", "3", nil},
+		{"bigint", "123456789012345678901234567890", "10", "123456789012345678901234567900", "123456789012345678901234567880", "1234567890123456789012345678900", "12345678901234567890123456789", nil},
+		{"rat", "1/3", "1/6", "1/2", "1/6", "1/18", "2", nil},
+		{"float", "0.5", "0.25", "0.75", "0.25", "0.125", "2", nil},
+		{"int", "1", "0", "1", "1", "0", "", ErrDivByZero},
+	}
+	for _, c := range cases {
+		a, err := ByName(c.mode, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, err1 := a.Parse(c.a)
+		y, err2 := a.Parse(c.b)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("%s: разбор %q, %q: %v %v", c.mode, c.a, c.b, err1, err2)
+		}
+		check := func(op, want string, v any, err error) {
+			t.Helper()
+			if err != nil {
+				t.Errorf("%s %s %s %s: %v", c.mode, c.a, op, c.b, err)
+			} else if got := a.String(v); got != want {
+				t.Errorf("%s %s %s %s = %s, ожидалось %s", c.mode, c.a, op, c.b, got, want)
+			}
+		}
+		sum, err := a.Add(x, y)
+		check("+", c.sum, sum, err)
+		diff, err := a.Sub(x, y)
+		check("-", c.diff, diff, err)
+		prod, err := a.Mul(x, y)
+		check("*", c.prod, prod, err)
+		quo, err := a.Quo(x, y)
+		if c.quoErr != nil {
+			if !errors.Is(err, c.quoErr) {
+				t.Errorf("%s %s / %s: ошибка %v, ожидалась %v", c.mode, c.a, c.b, err, c.quoErr)
+			}
+			continue
+		}
+		check("/", c.quo, quo, err)
+	}
+}
+
+func TestIntOverflow(t *testing.T) {
+	a := Int{}
+	if _, err := a.Mul(math.MaxInt, 2); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt*2: ошибка %v, ожидалась ErrOverflow", err)
+	}
+	if _, err := a.Add(math.MaxInt, 1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MaxInt+1: ошибка %v, ожидалась ErrOverflow", err)
+	}
+	if _, err := a.Quo(math.MinInt, -1); !errors.Is(err, ErrOverflow) {
+		t.Errorf("MinInt/-1: ошибка %v, ожидалась ErrOverflow", err)
+	}
+	if _, err := a.Parse("9223372036854775808"); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Parse(MaxInt64+1): ошибка %v, ожидалась strconv.ErrRange", err)
+	}
+}
@@ -0,0 +1,219 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// контрольные точки позволяют продолжить прерванный запуск над большим входом, а не начинать с нуля.
+//
+// каждый элемент несёт место во входе, откуда он прочитан (Record.Pos). приёмник подтверждает (Ack)
+// элемент после того, как полностью его обработал, а Checkpointer периодически записывает последнее
+// подтверждённое место в файл. перезапуск с тем же файлом читает вход с места после него (Input.Resume).
+//
+// гарантия - at-least-once: всё, что подтверждено и записано, повторно не обрабатывается, но элементы,
+// подтверждённые после последней записи, при аварийном завершении (kill -9, паника) будут обработаны ещё раз.
+// приёмник должен быть к этому готов: например, перезаписывать результат по ключу, а не дописывать.
+//
+// подтверждённое место считается водяным знаком только если все этапы между источником и приёмником
+// сохраняют порядок (один воркер или Ordered): тогда элемент, дошедший до приёмника, означает, что все элементы
+// до него уже обработаны или отброшены (Filter, OnError(Skip)). без сохранения порядка контрольная точка
+// может оказаться дальше необработанного элемента, и он будет потерян при перезапуске
+
+// Record - значение вместе с местом во входе, откуда оно прочитано
+type Record[T any] struct {
+	Value T
+	Pos   Position
+}
+
+// NumbersAt - как NumbersOf, но каждое число приходит вместе со своим местом во входе (для контрольных точек)
+func NumbersAt[T any](p *Pipeline, in Input, parse func(s string) (T, error), opts ...StageOption) Stream[Record[T]] {
+	parseToken := ParseWith(parse)
+	return MapErr(Tokens(p, in, Name("read")), func(ctx context.Context, t Token) (Record[T], error) {
+		v, err := parseToken(ctx, t)
+		return Record[T]{Value: v, Pos: t.After()}, err
+	}, append([]StageOption{Name("parse")}, opts...)...)
+}
+
+// MapRecord - MapErr над значениями записей: место во входе переносится в результат без изменений
+func MapRecord[T, U any](s Stream[Record[T]], f func(ctx context.Context, v T) (U, error), opts ...StageOption) Stream[Record[U]] {
+	return MapErr(s, func(ctx context.Context, r Record[T]) (Record[U], error) {
+		v, err := f(ctx, r.Value)
+		return Record[U]{Value: v, Pos: r.Pos}, err
+	}, opts...)
+}
+
+// Checkpoint - содержимое файла контрольной точки
+type Checkpoint struct {
+	Input []string  `json:"input"`    // Input.Paths запуска - чтобы не продолжить по контрольной точке другого входа
+	Pos   *Position `json:"position"` // место после последнего подтверждённого элемента; nil - ещё ничего не подтверждено
+	Items int64     `json:"items"`    // сколько элементов подтверждено за все запуски
+	Done  bool      `json:"done"`     // вход обработан полностью
+	Saved time.Time `json:"saved"`
+}
+
+// LoadCheckpoint читает контрольную точку; если файла нет, возвращает nil без ошибки
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("контрольная точка %s повреждена: %w", path, err)
+	}
+	return &cp, nil
+}
+
+// save атомарно записывает контрольную точку: во временный файл рядом, fsync и rename поверх старой.
+// при сбое посреди записи на диске остаётся либо старая, либо новая версия целиком
+func (cp *Checkpoint) save(path string) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // после успешного rename файла с этим именем уже нет
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Checkpointer принимает подтверждения от приёмника и раз в every сохраняет контрольную точку
+type Checkpointer struct {
+	path  string
+	every time.Duration
+
+	mu    sync.Mutex
+	cp    Checkpoint
+	dirty bool // есть подтверждения, ещё не записанные в файл
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenCheckpointer загружает контрольную точку из path (если она есть) для входа in
+// и запускает её периодическое сохранение. контрольная точка другого входа - ошибка.
+// stdin не поддерживается: при повторном запуске это уже другие данные, и смещение в них ничего не значит
+func OpenCheckpointer(path string, in Input, every time.Duration) (*Checkpointer, error) {
+	if slices.Contains(in.Paths, "-") {
+		return nil, errors.New("контрольная точка не поддерживается для stdin (-): продолжить можно только чтение файлов")
+	}
+	prev, err := LoadCheckpoint(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Checkpointer{
+		path:  path,
+		every: max(every, 10*time.Millisecond),
+		cp:    Checkpoint{Input: slices.Clone(in.Paths)},
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if prev != nil {
+		if !slices.Equal(prev.Input, in.Paths) {
+			return nil, fmt.Errorf("контрольная точка %s относится к другому входу %q; удалите её, чтобы начать заново", path, prev.Input)
+		}
+		c.cp = *prev
+	}
+	go c.loop()
+	return c, nil
+}
+
+// Resume возвращает место, с которого нужно продолжить чтение (для Input.Resume); nil - с начала
+func (c *Checkpointer) Resume() *Position {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cp.Pos
+}
+
+// Done сообщает, что вход по этой контрольной точке уже обработан полностью
+func (c *Checkpointer) Done() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cp.Done
+}
+
+// Items возвращает, сколько элементов подтверждено за все запуски
+func (c *Checkpointer) Items() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cp.Items
+}
+
+// Ack подтверждает, что элемент из места pos полностью обработан приёмником
+func (c *Checkpointer) Ack(pos Position) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cp.Pos = &pos
+	c.cp.Items++
+	c.dirty = true
+}
+
+func (c *Checkpointer) loop() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.flush(false) // ошибка периодической записи не фатальна: Close запишет ещё раз и вернёт её
+		}
+	}
+}
+
+// flush записывает контрольную точку, если с прошлой записи что-то подтверждено (или force)
+func (c *Checkpointer) flush(force bool) error {
+	c.mu.Lock()
+	if !c.dirty && !force {
+		c.mu.Unlock()
+		return nil
+	}
+	c.cp.Saved = time.Now()
+	cp := c.cp
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err := cp.save(c.path); err != nil {
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+		return fmt.Errorf("запись контрольной точки %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// Close останавливает периодическое сохранение и записывает контрольную точку в последний раз.
+// complete = true - вход обработан до конца без ошибок: точка помечается Done, и повторный запуск ничего не делает
+func (c *Checkpointer) Close(complete bool) error {
+	close(c.stop)
+	<-c.done
+	c.mu.Lock()
+	c.cp.Done = complete
+	c.mu.Unlock()
+	return c.flush(true)
+}
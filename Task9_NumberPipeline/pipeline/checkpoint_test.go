@@ -0,0 +1,155 @@
+package pipeline
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// numbersText - числа from..to по одному на строку
+func numbersText(from, to int) string {
+	var b strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&b, "%d\n", i)
+	}
+	return b.String()
+}
+
+func writeFile(t *testing.T, path, text string, gz bool) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if !gz {
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := zw.Write([]byte(text)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runCheckpointed выполняет один запуск над in с контрольной точкой ckPath и возвращает подтверждённые числа.
+// stopAfter > 0 - после стольких подтверждений конвейер мягко останавливается (как по первому Ctrl+C).
+// контрольная точка пишется только в Close, поэтому результат не зависит от периодического сохранения
+func runCheckpointed(t *testing.T, in Input, ckPath string, stopAfter int) []int {
+	t.Helper()
+	ck, err := OpenCheckpointer(ckPath, in, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ck.Done() {
+		t.Fatal("вход уже обработан полностью")
+	}
+	in.Resume = ck.Resume()
+
+	p := New(context.Background())
+	var acked []int
+	err = NumbersAt(p, in, strconv.Atoi).ForEach(func(r Record[int]) error {
+		acked = append(acked, r.Value)
+		ck.Ack(r.Pos)
+		if len(acked) == stopAfter {
+			p.Stop()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ck.Close(!p.Interrupted()); err != nil {
+		t.Fatal(err)
+	}
+	return acked
+}
+
+// прерванный запуск продолжается ровно с первого неподтверждённого числа - в том числе на границе файлов и в gzip
+func TestCheckpointResume(t *testing.T) {
+	for _, gz := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%v", gz), func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "a.txt"), numbersText(1, 700), gz)
+			writeFile(t, filepath.Join(dir, "b.txt"), numbersText(701, 1500), gz)
+			in := Input{Paths: []string{filepath.Join(dir, "*.txt")}}
+			ckPath := filepath.Join(dir, "ck.json")
+
+			var all []int
+			for _, stopAfter := range []int{250, 500, 0} { // второй запуск останавливается уже во втором файле
+				got := runCheckpointed(t, in, ckPath, stopAfter)
+				if len(got) == 0 || got[0] != len(all)+1 {
+					t.Fatalf("после %d подтверждённых чисел запуск начался с %v", len(all), got[:min(len(got), 1)])
+				}
+				all = append(all, got...)
+			}
+			want := make([]int, 1500)
+			for i := range want {
+				want[i] = i + 1
+			}
+			if !slices.Equal(all, want) {
+				t.Fatalf("за три запуска обработано %d чисел, есть пропуски или повторы", len(all))
+			}
+
+			cp, err := LoadCheckpoint(ckPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cp.Done || cp.Items != 1500 {
+				t.Fatalf("итоговая контрольная точка: Done=%v Items=%d", cp.Done, cp.Items)
+			}
+		})
+	}
+}
+
+// полностью обработанный вход помечается Done, и повторный запуск видит это до чтения входа
+func TestCheckpointDone(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.txt")
+	writeFile(t, path, numbersText(1, 10), false)
+	in := Input{Paths: []string{path}}
+	ckPath := filepath.Join(dir, "ck.json")
+
+	if got := runCheckpointed(t, in, ckPath, 0); len(got) != 10 {
+		t.Fatalf("обработано %d чисел, ожидалось 10", len(got))
+	}
+	ck, err := OpenCheckpointer(ckPath, in, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ck.Close(true)
+	if !ck.Done() || ck.Items() != 10 {
+		t.Fatalf("Done=%v Items=%d, ожидалось true и 10", ck.Done(), ck.Items())
+	}
+}
+
+// контрольная точка другого входа не применяется: иначе чтение продолжилось бы с чужого смещения
+func TestCheckpointOtherInput(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	writeFile(t, a, numbersText(1, 10), false)
+	writeFile(t, b, numbersText(1, 10), false)
+	ckPath := filepath.Join(dir, "ck.json")
+
+	runCheckpointed(t, Input{Paths: []string{a}}, ckPath, 3)
+	if _, err := OpenCheckpointer(ckPath, Input{Paths: []string{b}}, time.Hour); err == nil || !strings.Contains(err.Error(), "другому входу") {
+		t.Fatalf("ожидалась ошибка про другой вход, получено %v", err)
+	}
+
+	// stdin при повторном запуске - другие данные, продолжать его нельзя
+	if _, err := OpenCheckpointer(ckPath, Input{Paths: []string{"-"}}, time.Hour); err == nil || !strings.Contains(err.Error(), "stdin") {
+		t.Fatalf("ожидалась ошибка про stdin, получено %v", err)
+	}
+}
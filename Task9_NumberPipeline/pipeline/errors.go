@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrorPolicy - что делать, если функция этапа вернула ошибку
+type ErrorPolicy int
+
+const (
+	// FailFast (по умолчанию) - отменить весь конвейер; ошибка вернётся из Wait
+	FailFast ErrorPolicy = iota
+	// Skip - пропустить элемент и продолжить; все пропущенные ошибки вернутся из Wait одной ошибкой (errors.Join)
+	Skip
+	// ToSink - пропустить элемент и отправить ошибку в канал, заданный опцией ErrorSink
+	ToSink
+)
+
+// StageError - ошибка этапа с контекстом: имя этапа и элемент, на котором она произошла
+type StageError struct {
+	Stage string
+	Item  any // nil для ошибок источника
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	var pe *ParseError
+	if e.Item == nil || errors.As(e.Err, &pe) { // ParseError сам указывает место во входных данных
+		return fmt.Sprintf("этап %s: %v", e.Stage, e.Err)
+	}
+	return fmt.Sprintf("этап %s, элемент %v: %v", e.Stage, e.Item, e.Err)
+}
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+// OnError задаёт политику обработки ошибок этапа
+func OnError(policy ErrorPolicy) StageOption {
+	return func(c *stageConfig) {
+		c.policy = policy
+	}
+}
+
+// ErrorSink включает политику ToSink: ошибки этапа отправляются в sink.
+// канал нужно вычитывать, иначе этап заблокируется (до отмены конвейера)
+func ErrorSink(sink chan<- *StageError) StageOption {
+	return func(c *stageConfig) {
+		c.policy = ToSink
+		c.errSink = sink
+	}
+}
+
+// handle применяет политику этапа к ошибке на элементе v;
+// возвращает false, если этап должен завершиться (конвейер отменён)
+func (st *stage[T]) handle(v T, err error) bool {
+	if errors.Is(err, context.Canceled) && st.p.ctx.Err() != nil {
+		return false // ошибка - следствие отмены конвейера, а не сбой этапа
+	}
+	se := &StageError{Stage: st.name, Item: v, Err: err}
+	switch st.cfg.policy {
+	case Skip:
+		st.p.report(se)
+		return true
+	case ToSink:
+		if st.cfg.errSink == nil { // ErrorSink не задан - сообщаем как при Skip, чтобы ошибка не потерялась
+			st.p.report(se)
+			return true
+		}
+		return send(st.p.ctx, st.cfg.errSink, se)
+	default:
+		st.p.fail(se)
+		return false
+	}
+}
+
+// fail запоминает первую ошибку и отменяет конвейер
+func (p *Pipeline) fail(err error) {
+	p.errMu.Lock()
+	if p.failed == nil {
+		p.failed = err
+	}
+	p.errMu.Unlock()
+	p.cancel()
+}
+
+// report запоминает ошибку пропущенного элемента
+func (p *Pipeline) report(err error) {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	p.reported = append(p.reported, err)
+}
+
+// Err возвращает итог конвейера на текущий момент (см. Wait)
+func (p *Pipeline) Err() error {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	switch {
+	case p.failed != nil:
+		return p.failed
+	case len(p.reported) > 0:
+		return errors.Join(p.reported...)
+	default:
+		return p.parent.Err()
+	}
+}
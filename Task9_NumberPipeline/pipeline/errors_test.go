@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// политики ошибок этапа: что доходит до приёмника, что уходит в ErrorSink и что возвращает Wait
+func TestErrorPolicies(t *testing.T) {
+	errOdd := errors.New("нечётное число")
+	cases := []struct {
+		name     string
+		opts     []StageOption
+		withSink bool  // передать этапу ErrorSink
+		want     []int // что дойдёт до приёмника
+		wantErrs []any // элементы с ошибкой в Wait в порядке входа (nil - Wait вернёт nil)
+		wantSink []any // элементы с ошибкой в ErrorSink
+	}{
+		{"FailFast по умолчанию", nil, false, []int{2}, []any{3}, nil},
+		{"Skip", []StageOption{OnError(Skip)}, false, []int{2, 4, 6}, []any{3, 1, 5}, nil},
+		{"ToSink", nil, true, []int{2, 4, 6}, nil, []any{3, 1, 5}},
+		{"ToSink без канала - как Skip", []StageOption{OnError(ToSink)}, false, []int{2, 4, 6}, []any{3, 1, 5}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := New(context.Background())
+			// первое число чётное: при FailFast до приёмника успевает дойти ровно оно
+			src := FromSlice(p, []int{2, 3, 4, 1, 6, 5})
+			opts := append([]StageOption{Name("check")}, c.opts...)
+			var sinkErrs chan *StageError
+			if c.withSink {
+				sinkErrs = make(chan *StageError, 8)
+				opts = append(opts, ErrorSink(sinkErrs))
+			}
+			checked := MapErr(src, func(_ context.Context, v int) (int, error) {
+				if v%2 != 0 {
+					return 0, errOdd
+				}
+				return v, nil
+			}, opts...)
+			var got []int
+			err := checked.ForEach(func(v int) error {
+				got = append(got, v)
+				return nil
+			})
+
+			if !slices.Equal(got, c.want) {
+				t.Errorf("получено %v, ожидалось %v", got, c.want)
+			}
+			if items := failedItems(t, err); !slices.Equal(items, c.wantErrs) {
+				t.Errorf("Wait вернул ошибки на элементах %v (%v), ожидалось %v", items, err, c.wantErrs)
+			}
+			if err != nil && !errors.Is(err, errOdd) {
+				t.Errorf("ошибка Wait %v не содержит ошибку этапа", err)
+			}
+			var sunk []any
+			if sinkErrs != nil {
+				close(sinkErrs)
+				for se := range sinkErrs {
+					if se.Stage != "check" || !errors.Is(se, errOdd) {
+						t.Errorf("в ErrorSink ошибка %v", se)
+					}
+					sunk = append(sunk, se.Item)
+				}
+			}
+			if !slices.Equal(sunk, c.wantSink) {
+				t.Errorf("в ErrorSink элементы %v, ожидалось %v", sunk, c.wantSink)
+			}
+		})
+	}
+}
+
+// failedItems возвращает элементы StageError из итога Wait: одна ошибка для FailFast или errors.Join для Skip
+func failedItems(t *testing.T, err error) []any {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	var items []any
+	for _, e := range errs {
+		var se *StageError
+		if !errors.As(e, &se) {
+			t.Fatalf("ошибка %v - не StageError", e)
+		}
+		items = append(items, se.Item)
+	}
+	return items
+}
+
+// итог Wait: FailFast важнее пропущенных ошибок, ошибка источника - без элемента,
+// отмена родительского контекста - его ошибка, а Cancel самого конвейера ошибкой не считается
+func TestWaitResult(t *testing.T) {
+	errBad := errors.New("сбой")
+	fail := func(v int) func(context.Context, int) (int, error) {
+		return func(_ context.Context, x int) (int, error) {
+			if x == v {
+				return 0, errBad
+			}
+			return x, nil
+		}
+	}
+
+	// пропущенная на первом этапе ошибка и FailFast на втором - Wait возвращает только FailFast
+	p := New(context.Background())
+	s := MapErr(FromSlice(p, []int{1, 2, 3}), fail(1), Name("skip"), OnError(Skip))
+	err := MapErr(s, fail(3), Name("fail")).ForEach(func(int) error { return nil })
+	var se *StageError
+	if !errors.As(err, &se) || se.Stage != "fail" || se.Item != 3 {
+		t.Errorf("Skip + FailFast: Wait вернул %v, ожидалась ошибка этапа fail на 3", err)
+	}
+	if got := p.Err(); got != err {
+		t.Errorf("Err() после Wait = %v, ожидалось то же, что вернул Wait", got)
+	}
+
+	// ошибка источника останавливает конвейер и приходит без элемента
+	p = New(context.Background())
+	src := Source(p, func(_ context.Context, emit func(int) bool) error {
+		emit(1)
+		return errBad
+	}, Name("gen"))
+	err = src.ForEach(func(int) error { return nil })
+	if !errors.As(err, &se) || se.Stage != "gen" || se.Item != nil || se.Error() != "этап gen: сбой" {
+		t.Errorf("ошибка источника: %v", err)
+	}
+
+	// ошибка приёмника с FailFast - с этапом и элементом в тексте
+	p = New(context.Background())
+	err = FromSlice(p, []int{1, 2}).ForEach(func(v int) error {
+		if v == 2 {
+			return errBad
+		}
+		return nil
+	}, Name("out"))
+	if err == nil || err.Error() != "этап out, элемент 2: сбой" {
+		t.Errorf("ошибка приёмника: %v", err)
+	}
+
+	// отмена родительского контекста - Wait возвращает context.Canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	p = New(ctx)
+	cancel()
+	if err := FromSlice(p, []int{1, 2, 3}).ForEach(func(int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("отмена родительского контекста: Wait вернул %v", err)
+	}
+
+	// Cancel самого конвейера - не ошибка, но Interrupted() сообщает о недочитанном входе
+	p = New(context.Background())
+	p.Cancel()
+	if err := FromSlice(p, []int{1, 2, 3}).ForEach(func(int) error { return nil }); err != nil || !p.Interrupted() {
+		t.Errorf("Cancel: Wait вернул %v, Interrupted() = %v", err, p.Interrupted())
+	}
+}
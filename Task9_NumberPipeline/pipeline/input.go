@@ -0,0 +1,291 @@
+package pipeline
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Separator - как разделены числа во входных данных
+type Separator int
+
+const (
+	SepNewline    Separator = iota // одно число на строку
+	SepComma                       // числа через запятую (перевод строки тоже разделяет)
+	SepWhitespace                  // числа через пробелы, табуляции и переводы строк
+)
+
+// ParseSeparator разбирает название разделителя: newline, comma или space
+func ParseSeparator(s string) (Separator, error) {
+	switch s {
+	case "newline", "line", "":
+		return SepNewline, nil
+	case "comma", ",":
+		return SepComma, nil
+	case "space", "whitespace":
+		return SepWhitespace, nil
+	}
+	return 0, fmt.Errorf("неизвестный разделитель %q (допустимо: newline, comma, space)", s)
+}
+
+// maxTokenLen - предел длины одного токена: без него строка без разделителей могла бы занять всю память
+const maxTokenLen = 1 << 16
+
+// Token - фрагмент входных данных между разделителями и его место во входе
+type Token struct {
+	Text   string
+	File   string // путь к файлу или "-" для stdin
+	Line   int    // номер строки, с 1
+	Offset int64  // смещение начала токена в (распакованном) потоке, байт
+	End    int64  // смещение сразу после токена
+}
+
+// Position - место во входных данных сразу после токена: с него продолжается прерванный запуск (см. checkpoint.go)
+type Position struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Offset int64  `json:"offset"` // смещение в (распакованном) потоке файла, байт
+}
+
+// After возвращает место сразу после токена
+func (t Token) After() Position {
+	return Position{File: t.File, Line: t.Line, Offset: t.End}
+}
+
+// ParseError - ошибка разбора числа с указанием места во входных данных
+type ParseError struct {
+	File   string
+	Line   int
+	Offset int64
+	Text   string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d (смещение %d): некорректное число %q: %v", e.File, e.Line, e.Offset, e.Text, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Input описывает, откуда читать числа
+type Input struct {
+	// Paths - пути или шаблоны (filepath.Glob); "-" означает stdin. файлы читаются по очереди, в порядке шаблонов,
+	// а файлы одного шаблона - по алфавиту. сжатые gzip файлы распознаются по сигнатуре и распаковываются на лету
+	Paths []string
+	Sep   Separator
+	Stdin io.Reader // источник для "-"; nil - os.Stdin
+
+	// Resume - продолжить чтение с этого места: файлы до Resume.File пропускаются целиком,
+	// а в нём самом - первые Resume.Offset байт. nil - читать с начала
+	Resume *Position
+}
+
+// files раскрывает шаблоны в список путей
+func (in Input) files() ([]string, error) {
+	var files []string
+	for _, pattern := range in.Paths {
+		if pattern == "-" {
+			files = append(files, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("шаблон %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("по шаблону %q не найдено ни одного файла", pattern)
+		}
+		slices.Sort(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// open открывает вход (файл или stdin) и при необходимости подключает распаковку gzip
+func (in Input) open(path string) (*bufio.Reader, func() error, error) {
+	var (
+		r       io.Reader
+		closeFn = func() error { return nil }
+	)
+	if path == "-" {
+		r = in.Stdin
+		if r == nil {
+			r = os.Stdin
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, closeFn = f, f.Close
+	}
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			closeFn()
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		fileClose := closeFn
+		closeFn = func() error { return errors.Join(zr.Close(), fileClose()) }
+		return bufio.NewReader(zr), closeFn, nil
+	}
+	return br, closeFn, nil
+}
+
+// Tokens - источник, лениво читающий токены из входов по одному файлу за раз: память не зависит от размера входа.
+// ошибка чтения или открытия файла останавливает конвейер
+func Tokens(p *Pipeline, in Input, opts ...StageOption) Stream[Token] {
+	return Source(p, func(ctx context.Context, emit func(Token) bool) error {
+		files, err := in.files()
+		if err != nil {
+			return err
+		}
+		from := Position{Line: 1}
+		if in.Resume != nil {
+			i := slices.Index(files, in.Resume.File)
+			if i < 0 {
+				return fmt.Errorf("файл %s из контрольной точки не входит в список входных файлов", in.Resume.File)
+			}
+			files, from = files[i:], *in.Resume
+		}
+		for _, path := range files {
+			err := in.scanFile(path, from, emit)
+			from = Position{Line: 1} // следующие файлы читаются с начала
+			if err != nil {
+				if errors.Is(err, errStopped) {
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// errStopped - emit вернул false: конвейер отменён, читать дальше не нужно
+var errStopped = errors.New("конвейер остановлен")
+
+// scanFile читает токены одного входа, начиная с места from.
+// уже обработанная часть не разбирается, а просто вычитывается: у gzip нет произвольного доступа,
+// а смещения в контрольной точке считаются по распакованному потоку
+func (in Input) scanFile(path string, from Position, emit func(Token) bool) error {
+	r, closeFn, err := in.open(path)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	if from.Offset > 0 {
+		if _, err := io.CopyN(io.Discard, r, from.Offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%s: файл короче смещения %d из контрольной точки", path, from.Offset)
+			}
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return scanTokens(r, path, in.Sep, from, emit)
+}
+
+// isSep сообщает, является ли байт разделителем токенов
+func isSep(sep Separator, b byte) bool {
+	switch sep {
+	case SepComma:
+		return b == ',' || b == '\n'
+	case SepWhitespace:
+		return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+	default:
+		return b == '\n'
+	}
+}
+
+// scanTokens побайтно разбирает поток на токены, отслеживая номер строки и смещение;
+// from задаёт строку и смещение, с которых начинается r. пробелы вокруг токена отбрасываются, пустые токены пропускаются
+func scanTokens(r *bufio.Reader, file string, sep Separator, from Position, emit func(Token) bool) error {
+	var (
+		buf    []byte
+		offset = from.Offset // смещение текущего байта
+		line   = max(from.Line, 1)
+		start  int64 // смещение начала текущего токена
+		tokLn  int   // строка начала текущего токена
+	)
+	flush := func() error {
+		text := strings.TrimSpace(string(buf))
+		buf = buf[:0]
+		if text == "" {
+			return nil
+		}
+		if !emit(Token{Text: text, File: file, Line: tokLn, Offset: start, End: offset}) {
+			return errStopped
+		}
+		return nil
+	}
+
+	for ; ; offset++ {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: ошибка чтения: %w", file, line, err)
+		}
+
+		if isSep(sep, b) {
+			if err := flush(); err != nil {
+				return err
+			}
+		} else if len(buf) > 0 || !isSep(SepWhitespace, b) { // пробелы перед токеном - не его начало
+			if len(buf) == 0 {
+				start, tokLn = offset, line
+			}
+			if len(buf) >= maxTokenLen {
+				return &ParseError{File: file, Line: tokLn, Offset: start, Text: string(buf[:32]) + "...",
+					Err: fmt.Errorf("токен длиннее %d байт", maxTokenLen)}
+			}
+			buf = append(buf, b)
+		}
+		if b == '\n' {
+			line++
+		}
+	}
+}
+
+// ParseInt разбирает токен как целое число; ошибка содержит файл, строку и смещение токена
+func ParseInt(ctx context.Context, t Token) (int, error) {
+	return ParseWith(strconv.Atoi)(ctx, t)
+}
+
+// ParseWith превращает функцию разбора строки (например, Parse одного из режимов numeric)
+// в функцию этапа над токенами; ошибка содержит файл, строку и смещение токена
+func ParseWith[T any](parse func(s string) (T, error)) func(ctx context.Context, t Token) (T, error) {
+	return func(_ context.Context, t Token) (T, error) {
+		v, err := parse(t.Text)
+		if err != nil {
+			var numErr *strconv.NumError
+			if errors.As(err, &numErr) {
+				err = numErr.Err
+			}
+			return v, &ParseError{File: t.File, Line: t.Line, Offset: t.Offset, Text: t.Text, Err: err}
+		}
+		return v, nil
+	}
+}
+
+// Numbers - источник целых чисел из файлов или stdin: Tokens + ParseInt.
+// opts относятся к этапу разбора: например, OnError(Skip) пропускает некорректные числа вместо остановки конвейера
+func Numbers(p *Pipeline, in Input, opts ...StageOption) Stream[int] {
+	return NumbersOf(p, in, strconv.Atoi, opts...)
+}
+
+// NumbersOf - как Numbers, но числа разбираются функцией parse, например numeric.BigInt{}.Parse
+func NumbersOf[T any](p *Pipeline, in Input, parse func(s string) (T, error), opts ...StageOption) Stream[T] {
+	return MapErr(Tokens(p, in, Name("read")), ParseWith(parse), append([]StageOption{Name("parse")}, opts...)...)
+}
@@ -0,0 +1,125 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readTokens читает все токены входа
+func readTokens(t *testing.T, in Input) ([]Token, error) {
+	t.Helper()
+	p := New(context.Background())
+	var got []Token
+	err := Tokens(p, in).ForEach(func(tok Token) error {
+		got = append(got, tok)
+		return nil
+	})
+	return got, err
+}
+
+// tok - ожидаемый токен: текст, строка, начало и конец
+type tok struct {
+	text   string
+	line   int
+	offset int64
+	end    int64
+}
+
+// номера строк и смещения токенов при разных разделителях; пробелы вокруг токена в его место не входят
+func TestTokenPositions(t *testing.T) {
+	cases := []struct {
+		name  string
+		sep   Separator
+		input string
+		want  []tok
+	}{
+		{"newline", SepNewline, "1\n  22 \n\n333\r\n", []tok{{"1", 1, 0, 1}, {"22", 2, 4, 7}, {"333", 4, 9, 13}}},
+		{"comma", SepComma, "1,2\n3, x ,4", []tok{{"1", 1, 0, 1}, {"2", 1, 2, 3}, {"3", 2, 4, 5}, {"x", 2, 7, 9}, {"4", 2, 10, 11}}},
+		{"comma: пустые поля пропускаются", SepComma, ",,5,\n,6", []tok{{"5", 1, 2, 3}, {"6", 2, 6, 7}}},
+		{"space", SepWhitespace, " 7\t8\n\n  9 ", []tok{{"7", 1, 1, 2}, {"8", 1, 3, 4}, {"9", 3, 8, 9}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := readTokens(t, Input{Paths: []string{"-"}, Sep: c.sep, Stdin: strings.NewReader(c.input)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var have []tok
+			for _, g := range got {
+				if g.File != "-" {
+					t.Errorf("токен %q из файла %q, ожидался stdin", g.Text, g.File)
+				}
+				have = append(have, tok{g.Text, g.Line, g.Offset, g.End})
+			}
+			if !slices.Equal(have, c.want) {
+				t.Fatalf("токены %v, ожидалось %v", have, c.want)
+			}
+		})
+	}
+
+	// место некорректного числа в ошибке указывает на сам токен, а не на пробелы перед ним
+	p := New(context.Background())
+	err := Numbers(p, Input{Paths: []string{"-"}, Sep: SepComma, Stdin: strings.NewReader("1,2\n3, x ,4")}).
+		ForEach(func(int) error { return nil })
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 2 || pe.Offset != 7 || pe.Text != "x" {
+		t.Fatalf("ошибка разбора: %v", err)
+	}
+}
+
+// файлы одного шаблона читаются по алфавиту, шаблоны - в заданном порядке, gzip распознаётся по сигнатуре
+// (а не по расширению), и смещения считаются по распакованному потоку
+func TestInputFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "b.txt"), "3\n4\n", false)
+	writeFile(t, filepath.Join(dir, "a.txt"), "1\n2\n", true) // сжат, хотя расширение .txt
+	writeFile(t, filepath.Join(dir, "c.num"), "5\n", false)
+
+	got, err := readTokens(t, Input{Paths: []string{filepath.Join(dir, "c.num"), filepath.Join(dir, "*.txt")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts, files []string
+	for _, g := range got {
+		texts = append(texts, g.Text)
+		files = append(files, filepath.Base(g.File))
+	}
+	if want := []string{"5", "1", "2", "3", "4"}; !slices.Equal(texts, want) {
+		t.Fatalf("прочитано %v, ожидалось %v", texts, want)
+	}
+	if want := []string{"c.num", "a.txt", "a.txt", "b.txt", "b.txt"}; !slices.Equal(files, want) {
+		t.Fatalf("файлы токенов %v, ожидалось %v", files, want)
+	}
+	if got[2].Offset != 2 || got[2].Line != 2 {
+		t.Fatalf("второй токен сжатого файла: строка %d, смещение %d; ожидалось 2, 2", got[2].Line, got[2].Offset)
+	}
+
+	// шаблон без совпадений - ошибка, а не пустой вход
+	if _, err := readTokens(t, Input{Paths: []string{filepath.Join(dir, "*.csv")}}); err == nil ||
+		!strings.Contains(err.Error(), "не найдено ни одного файла") {
+		t.Fatalf("шаблон без файлов: %v", err)
+	}
+}
+
+// токен длиннее maxTokenLen останавливает чтение с ошибкой, указывающей на его начало
+func TestMaxTokenLen(t *testing.T) {
+	input := "1\n  " + strings.Repeat("7", maxTokenLen+1) + "\n2\n"
+	got, err := readTokens(t, Input{Paths: []string{"-"}, Stdin: strings.NewReader(input)})
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 2 || pe.Offset != 4 || !strings.Contains(pe.Error(), "токен длиннее") {
+		t.Fatalf("ошибка длинного токена: %v", err)
+	}
+	if len(got) != 1 || got[0].Text != "1" {
+		t.Fatalf("до длинного токена прочитано %v, ожидалось только 1", got)
+	}
+
+	// токен ровно maxTokenLen байт ещё допустим
+	input = strings.Repeat("7", maxTokenLen)
+	if got, err := readTokens(t, Input{Paths: []string{"-"}, Stdin: strings.NewReader(input)}); err != nil || len(got) != 1 {
+		t.Fatalf("токен длиной maxTokenLen: %d токенов, ошибка %v", len(got), err)
+	}
+}
@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// fanOut - параллельный этап без сохранения порядка: n копий читают общий вход и пишут в общий выход,
+// выходной канал закрывается, когда завершились все копии
+func fanOut[T, U any](s Stream[T], st *stage[T], f func(ctx context.Context, v T) ([]U, error)) Stream[U] {
+	p, n := s.p, st.cfg.workers
+	out := output[U](st.stats, st.cfg.buffer)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		p.spawn(func() {
+			defer wg.Done()
+			defer st.stats.track()()
+			process(st, s.ch, out, f)
+		})
+	}
+	p.spawn(func() {
+		wg.Wait()
+		close(out)
+	})
+	return Stream[U]{p: p, ch: out}
+}
+
+// indexed - элемент с порядковым номером во входном потоке
+type indexed[V any] struct {
+	i uint64
+	v V
+}
+
+// fanOutOrdered - параллельный этап с сохранением порядка:
+// раздатчик нумерует входные элементы, n копий обрабатывают их, а сборщик выдаёт результаты по номерам.
+// слоты (slots) ограничивают число элементов "в работе" и тем самым размер буфера сборщика.
+// в статистику этапа идёт работа n копий: ожидание задачи от раздатчика считается ожиданием входа,
+// ожидание сборщика - ожиданием отправки
+func fanOutOrdered[T, U any](s Stream[T], st *stage[T], f func(ctx context.Context, v T) ([]U, error)) Stream[U] {
+	p, n := s.p, st.cfg.workers
+	tasks := make(chan indexed[T])
+	results := make(chan indexed[[]U])
+	slots := make(chan struct{}, 2*n)
+	out := output[U](st.stats, st.cfg.buffer)
+
+	// раздатчик: нумерует элементы и занимает слот на каждый
+	p.spawn(func() {
+		defer close(tasks)
+		for i := uint64(0); ; i++ {
+			v, ok := recv(p.ctx, s.ch)
+			if !ok || !send(p.ctx, slots, struct{}{}) || !send(p.ctx, tasks, indexed[T]{i: i, v: v}) {
+				return
+			}
+			st.stats.in.Add(1)
+		}
+	})
+
+	// n копий этапа
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		p.spawn(func() {
+			defer wg.Done()
+			defer st.stats.track()()
+			for {
+				t, ok := waitRecv(st.stats, p.ctx, tasks)
+				if !ok {
+					return
+				}
+				// элемент с ошибкой всё равно отправляется сборщику (с пустым результатом), иначе сборщик ждал бы его номер вечно
+				us, err := f(p.ctx, t.v)
+				if err != nil && !st.handle(t.v, err) {
+					return
+				}
+				if !waitSend(st.stats, p.ctx, results, indexed[[]U]{i: t.i, v: us}) {
+					return
+				}
+			}
+		})
+	}
+	p.spawn(func() {
+		wg.Wait()
+		close(results)
+	})
+
+	// сборщик: выдаёт результаты строго по номерам и освобождает слоты
+	p.spawn(func() {
+		defer close(out)
+		pending := make(map[uint64][]U, 2*n)
+		next := uint64(0)
+		for {
+			r, ok := recv(p.ctx, results)
+			if !ok {
+				return
+			}
+			pending[r.i] = r.v
+			for {
+				vs, ready := pending[next]
+				if !ready {
+					break
+				}
+				for _, u := range vs {
+					if !send(p.ctx, out, u) {
+						return
+					}
+					st.stats.out.Add(1)
+				}
+				delete(pending, next)
+				next++
+				<-slots
+			}
+		}
+	})
+	return Stream[U]{p: p, ch: out}
+}
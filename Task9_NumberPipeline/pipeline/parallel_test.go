@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Ordered выдаёт результаты в порядке входа, даже когда копии заканчивают в обратном порядке
+func TestOrderedOutOfOrderFinish(t *testing.T) {
+	const n = 4
+	var mu sync.Mutex
+	var finished []int
+	p := New(context.Background())
+	src := FromSlice(p, []int{0, 1, 2, 3, 4, 5, 6, 7})
+	// первые n элементов идут одновременно, и чем раньше элемент, тем дольше он обрабатывается
+	slow := Map(src, func(_ context.Context, v int) int {
+		if v < n {
+			time.Sleep(time.Duration(n-v) * 20 * time.Millisecond)
+		}
+		mu.Lock()
+		finished = append(finished, v)
+		mu.Unlock()
+		return v
+	}, Workers(n), Ordered())
+	var got []int
+	slow.Sink(func(v int) { got = append(got, v) })
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !slices.Equal(got, want) {
+		t.Fatalf("порядок на выходе %v, ожидался %v", got, want)
+	}
+	if slices.IsSorted(finished) { // иначе тест ничего не проверил
+		t.Fatalf("копии закончили по порядку (%v) - проверка порядка не сработала", finished)
+	}
+}
+
+// элемент, пропущенный по Skip, не останавливает сборщик: он ждёт номера по порядку, и без пустого результата
+// для пропущенного номера конвейер встал бы, как только заняты все 2*n слотов
+func TestOrderedSkipKeepsCollector(t *testing.T) {
+	errBad := errors.New("плохой элемент")
+	items := make([]int, 100)
+	var want []int
+	for i := range items {
+		items[i] = i
+		if i%3 != 0 { // ошибка на каждом третьем, включая самый первый
+			want = append(want, i)
+		}
+	}
+
+	p := New(context.Background())
+	kept := MapErr(FromSlice(p, items), func(_ context.Context, v int) (int, error) {
+		if v%3 == 0 {
+			return 0, errBad
+		}
+		return v, nil
+	}, Workers(4), Ordered(), OnError(Skip))
+	var got []int
+	kept.Sink(func(v int) { got = append(got, v) })
+
+	done := make(chan error, 1)
+	go func() { done <- p.Wait() }()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		p.Cancel()
+		t.Fatal("упорядоченный этап встал после пропущенных элементов")
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("получено %v, ожидалось %v", got, want)
+	}
+	if !errors.Is(err, errBad) {
+		t.Fatalf("Wait вернул %v, ожидались пропущенные ошибки", err)
+	}
+}
@@ -0,0 +1,319 @@
+// Package pipeline - обобщённый конвейер на каналах, выросший из решения L1.9 (generateNumbers -> multiplyByTwo).
+// конвейер собирается из этапов: источник (Source, FromSlice), преобразования (Map, Filter, FlatMap, Batch)
+// и приёмник (Sink, ForEach). каждый этап - отдельная горутина, этапы соединены каналами.
+// все этапы следят за контекстом конвейера: отмена (Cancel или отмена родительского контекста)
+// останавливает всю цепочку, и ни одна горутина не остаётся заблокированной на канале.
+// мягкая остановка (Stop) останавливает только источники: остальные этапы дообрабатывают то, что уже в пути,
+// а оконные этапы (window.go) отдают незавершённые окна.
+// этапы могут возвращать ошибки; что с ними делать, решает политика этапа (см. errors.go)
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Pipeline владеет контекстом и горутинами всех этапов одного конвейера
+type Pipeline struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	srcCtx context.Context    // контекст источников: отменяется и Cancel, и Stop
+	stop   context.CancelFunc // мягкая остановка - отменяет только srcCtx
+
+	interrupted atomic.Bool // вызывались Stop или Cancel - вход мог быть прочитан не до конца
+
+	wg     sync.WaitGroup
+	stages atomic.Int64 // счётчик этапов для имён по умолчанию
+
+	statsMu sync.Mutex
+	stats   []*stageStats // статистика этапов в порядке создания (см. stats.go)
+
+	errMu    sync.Mutex
+	failed   error   // первая ошибка этапа с политикой FailFast
+	reported []error // ошибки этапов с политикой Skip
+}
+
+// New создаёт конвейер; отмена ctx останавливает все его этапы
+func New(ctx context.Context) *Pipeline {
+	p := &Pipeline{parent: ctx}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.srcCtx, p.stop = context.WithCancel(p.ctx)
+	return p
+}
+
+// Context возвращает контекст конвейера
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Cancel останавливает все этапы конвейера
+func (p *Pipeline) Cancel() {
+	p.interrupted.Store(true)
+	p.cancel()
+}
+
+// Stop - мягкая остановка: источники перестают выдавать элементы и закрывают свои потоки,
+// а остальные этапы дообрабатывают уже выданные элементы и завершаются как при конце входа.
+// повторный вызов ничего не делает; Cancel после Stop прерывает дообработку
+func (p *Pipeline) Stop() {
+	p.interrupted.Store(true)
+	p.stop()
+}
+
+// Interrupted сообщает, вызывались ли Stop или Cancel: если да, источники могли не дочитать вход
+func (p *Pipeline) Interrupted() bool {
+	return p.interrupted.Load()
+}
+
+// Wait ждёт завершения горутин всех этапов и возвращает итог запуска:
+// первую ошибку этапа с политикой FailFast, иначе объединение (errors.Join) ошибок, пропущенных
+// по политике Skip, иначе ошибку родительского контекста, если он был отменён; nil - всё обработано успешно
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+	p.cancel() // освобождаем ресурсы контекста
+	return p.Err()
+}
+
+// spawn запускает горутину этапа и учитывает её в WaitGroup конвейера
+func (p *Pipeline) spawn(f func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		f()
+	}()
+}
+
+// stageName возвращает имя этапа: заданное опцией Name или "<вид>-<номер>"
+func (p *Pipeline) stageName(cfg stageConfig, kind string) string {
+	n := p.stages.Add(1)
+	if cfg.name != "" {
+		return cfg.name
+	}
+	return fmt.Sprintf("%s-%d", kind, n)
+}
+
+// Stream - выход одного этапа конвейера, к которому подключается следующий этап.
+// методы (Filter, Sink, ForEach) - для этапов, не меняющих тип элементов; этапы, меняющие тип (Map, FlatMap, Batch),
+// сделаны функциями, потому что у методов в Go не может быть собственных параметров типа
+type Stream[T any] struct {
+	p  *Pipeline
+	ch <-chan T
+}
+
+// Chan возвращает канал элементов потока (для чтения вне конвейера)
+func (s Stream[T]) Chan() <-chan T {
+	return s.ch
+}
+
+// Pipeline возвращает конвейер, которому принадлежит поток
+func (s Stream[T]) Pipeline() *Pipeline {
+	return s.p
+}
+
+// send отправляет значение в канал или сообщает false, если конвейер отменён
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv читает значение из канала; ok = false, если канал закрыт или конвейер отменён
+func recv[T any](ctx context.Context, ch <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-ch:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
+
+// Source создаёт поток из функции-генератора: gen вызывает emit для каждого элемента
+// и должна завершиться, как только emit вернёт false (конвейер отменён или остановлен через Stop).
+// ctx генератора отменяется в обоих случаях. ошибка генератора останавливает весь конвейер и возвращается из Wait
+func Source[T any](p *Pipeline, gen func(ctx context.Context, emit func(T) bool) error, opts ...StageOption) Stream[T] {
+	st := newStage[T](p, opts, "source")
+	out := output[T](st.stats, st.cfg.buffer)
+	p.spawn(func() {
+		defer st.stats.track()()
+		defer close(out) // закрываем канал после отправки
+		// после Stop emit сразу возвращает false: иначе select в send мог бы ещё выбирать готовую отправку
+		err := gen(p.srcCtx, func(v T) bool { return p.srcCtx.Err() == nil && sendTimed(st.stats, p.srcCtx, out, v) })
+		if err != nil && !(errors.Is(err, context.Canceled) && p.srcCtx.Err() != nil) { // отмена после Stop - не ошибка
+			p.fail(&StageError{Stage: st.name, Err: err})
+		}
+	})
+	return Stream[T]{p: p, ch: out}
+}
+
+// FromSlice отправляет в поток элементы слайса по порядку
+func FromSlice[T any](p *Pipeline, items []T, opts ...StageOption) Stream[T] {
+	return Source(p, func(_ context.Context, emit func(T) bool) error {
+		for _, v := range items {
+			if !emit(v) {
+				return nil
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// FromChan подключает к конвейеру внешний канал; поток завершается, когда канал закрыт
+func FromChan[T any](p *Pipeline, ch <-chan T, opts ...StageOption) Stream[T] {
+	return Source(p, func(ctx context.Context, emit func(T) bool) error {
+		for {
+			v, ok := recv(ctx, ch)
+			if !ok || !emit(v) {
+				return nil
+			}
+		}
+	}, opts...)
+}
+
+// Map применяет f к каждому элементу потока; opts задают параллельность этапа (Workers, Ordered) и имя
+func Map[T, U any](s Stream[T], f func(ctx context.Context, v T) U, opts ...StageOption) Stream[U] {
+	return flatMap(s, "map", func(ctx context.Context, v T) ([]U, error) { return []U{f(ctx, v)}, nil }, opts)
+}
+
+// MapErr - Map, функция которого может вернуть ошибку; реакция на ошибку задаётся опцией OnError
+func MapErr[T, U any](s Stream[T], f func(ctx context.Context, v T) (U, error), opts ...StageOption) Stream[U] {
+	return flatMap(s, "map", func(ctx context.Context, v T) ([]U, error) {
+		u, err := f(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return []U{u}, nil
+	}, opts)
+}
+
+// FlatMap применяет f к каждому элементу и отправляет дальше все элементы результата по порядку
+func FlatMap[T, U any](s Stream[T], f func(ctx context.Context, v T) []U, opts ...StageOption) Stream[U] {
+	return flatMap(s, "flatmap", func(ctx context.Context, v T) ([]U, error) { return f(ctx, v), nil }, opts)
+}
+
+// FlatMapErr - FlatMap, функция которого может вернуть ошибку
+func FlatMapErr[T, U any](s Stream[T], f func(ctx context.Context, v T) ([]U, error), opts ...StageOption) Stream[U] {
+	return flatMap(s, "flatmap", f, opts)
+}
+
+// flatMap - общая реализация этапов-преобразований.
+// с опцией Workers(n) этап выполняется n параллельными копиями (см. parallel.go)
+func flatMap[T, U any](s Stream[T], kind string, f func(ctx context.Context, v T) ([]U, error), opts []StageOption) Stream[U] {
+	st := newStage[T](s.p, opts, kind)
+	if st.cfg.workers > 1 {
+		if st.cfg.ordered {
+			return fanOutOrdered(s, st, f)
+		}
+		return fanOut(s, st, f)
+	}
+
+	p := s.p
+	out := output[U](st.stats, st.cfg.buffer)
+	p.spawn(func() {
+		defer st.stats.track()()
+		defer close(out) // закрываем канал после обработки
+		process(st, s.ch, out, f)
+	})
+	return Stream[U]{p: p, ch: out}
+}
+
+// process - цикл одной копии этапа: читает вход, применяет f, отправляет результаты.
+// возвращается, когда вход закрыт, конвейер отменён или политика ошибок требует остановиться
+func process[T, U any](st *stage[T], in <-chan T, out chan<- U, f func(ctx context.Context, v T) ([]U, error)) {
+	ctx := st.p.ctx
+	for {
+		v, ok := recvTimed(st.stats, ctx, in)
+		if !ok {
+			return
+		}
+		us, err := f(ctx, v)
+		if err != nil && !st.handle(v, err) {
+			return
+		}
+		for _, u := range us {
+			if !sendTimed(st.stats, ctx, out, u) {
+				return
+			}
+		}
+	}
+}
+
+// Filter пропускает дальше только элементы, для которых keep возвращает true
+func (s Stream[T]) Filter(keep func(v T) bool, opts ...StageOption) Stream[T] {
+	return flatMap(s, "filter", func(_ context.Context, v T) ([]T, error) {
+		if keep(v) {
+			return []T{v}, nil
+		}
+		return nil, nil
+	}, opts)
+}
+
+// Batch собирает элементы в пачки по size штук; последняя пачка может быть неполной
+func Batch[T any](s Stream[T], size int, opts ...StageOption) Stream[[]T] {
+	size = max(size, 1)
+	p := s.p
+	st := newStage[T](p, opts, "batch")
+	out := output[[]T](st.stats, st.cfg.buffer)
+	p.spawn(func() {
+		defer st.stats.track()()
+		defer close(out)
+		batch := make([]T, 0, size)
+		for {
+			v, ok := recvTimed(st.stats, p.ctx, s.ch)
+			if !ok {
+				break
+			}
+			if batch = append(batch, v); len(batch) == size {
+				if !sendTimed(st.stats, p.ctx, out, batch) {
+					return
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+		// входной поток закончился - отправляем неполную пачку (если конвейер не отменён)
+		if len(batch) > 0 && p.ctx.Err() == nil {
+			sendTimed(st.stats, p.ctx, out, batch)
+		}
+	})
+	return Stream[[]T]{p: p, ch: out}
+}
+
+// Sink - последний этап: вызывает f для каждого элемента потока.
+// sink работает в горутине конвейера; дождаться его завершения можно через Pipeline.Wait
+func (s Stream[T]) Sink(f func(v T)) {
+	s.SinkErr(func(v T) error {
+		f(v)
+		return nil
+	})
+}
+
+// SinkErr - Sink, функция которого может вернуть ошибку; реакция на ошибку задаётся опцией OnError
+func (s Stream[T]) SinkErr(f func(v T) error, opts ...StageOption) {
+	p := s.p
+	st := newStage[T](p, opts, "sink")
+	p.spawn(func() {
+		defer st.stats.track()()
+		for {
+			v, ok := recvTimed(st.stats, p.ctx, s.ch)
+			if !ok {
+				return
+			}
+			if err := f(v); err != nil && !st.handle(v, err) {
+				return
+			}
+		}
+	})
+}
+
+// ForEach - запуск конвейера до конца: подключает приёмник f, ждёт завершения всех этапов
+// и возвращает итог, как Pipeline.Wait. заменяет ручной цикл for v := range output
+func (s Stream[T]) ForEach(f func(v T) error, opts ...StageOption) error {
+	s.SinkErr(f, opts...)
+	return s.p.Wait()
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// waitGoroutines ждёт, пока число горутин вернётся к base: горутины, уже отметившиеся в WaitGroup,
+// могут ещё не успеть завершиться
+func waitGoroutines(t *testing.T, base int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if left := runtime.NumGoroutine() - base; left > 0 {
+		t.Fatalf("после остановки конвейера осталось лишних горутин: %d", left)
+	}
+}
+
+// цепочка Map -> Filter -> FlatMap -> Batch -> Sink отдаёт те же значения, что и те же шаги в цикле
+func TestStages(t *testing.T) {
+	var want [][]int
+	var batch []int
+	for i := 1; i <= 10; i++ {
+		v := i * 2
+		if v%3 == 0 {
+			continue
+		}
+		if batch = append(batch, v, -v); len(batch) >= 4 {
+			want = append(want, batch[:4])
+			batch = batch[4:]
+		}
+	}
+	want = append(want, batch) // неполная последняя пачка
+
+	for _, workers := range []int{1, 4} {
+		p := New(context.Background())
+		src := FromSlice(p, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+		doubled := Map(src, func(_ context.Context, v int) int { return v * 2 }, Workers(workers), Ordered())
+		signed := FlatMap(doubled.Filter(func(v int) bool { return v%3 != 0 }),
+			func(_ context.Context, v int) []int { return []int{v, -v} })
+		var got [][]int
+		Batch(signed, 4).Sink(func(b []int) { got = append(got, b) })
+		if err := p.Wait(); err != nil {
+			t.Fatalf("workers=%d: Wait вернул %v", workers, err)
+		}
+		if !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+			t.Fatalf("workers=%d: получено %v, ожидалось %v", workers, got, want)
+		}
+		if p.Interrupted() {
+			t.Fatalf("workers=%d: конвейер без Stop и Cancel отмечен как прерванный", workers)
+		}
+	}
+}
+
+// Cancel посреди бесконечного входа останавливает все этапы, включая параллельные копии,
+// и ни одна горутина не остаётся висеть на канале
+func TestCancelMidway(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		base := runtime.NumGoroutine()
+		p := New(context.Background())
+		src := Source(p, func(_ context.Context, emit func(int) bool) error {
+			for i := 0; emit(i); i++ {
+			}
+			return nil
+		})
+		opts := []StageOption{Workers(4), Buffer(8)}
+		if ordered {
+			opts = append(opts, Ordered())
+		}
+		squares := Map(src, func(_ context.Context, v int) int { return v * v }, opts...)
+		got := 0
+		Batch(squares.Filter(func(v int) bool { return v%2 == 0 }), 3).Sink(func([]int) {
+			if got++; got == 100 {
+				p.Cancel()
+			}
+		})
+
+		done := make(chan error, 1)
+		go func() { done <- p.Wait() }()
+		select {
+		case err := <-done:
+			if err != nil { // родительский контекст не отменён - Cancel не ошибка
+				t.Fatalf("ordered=%v: Wait вернул %v", ordered, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("ordered=%v: конвейер не остановился после Cancel", ordered)
+		}
+		if !p.Interrupted() {
+			t.Fatalf("ordered=%v: после Cancel Interrupted() = false", ordered)
+		}
+		waitGoroutines(t, base)
+	}
+}
+
+// Stop останавливает только источник: неполная пачка в Batch всё равно доходит до приёмника
+func TestStopFlushesBatch(t *testing.T) {
+	p := New(context.Background())
+	emitted := make(chan struct{})
+	src := Source(p, func(ctx context.Context, emit func(int) bool) error {
+		for i := 1; i <= 5; i++ {
+			if !emit(i) {
+				return nil
+			}
+		}
+		close(emitted) // emit вернулся - значит, Batch уже принял 5
+		<-ctx.Done()   // дальше вход "зависает", пока конвейер не остановят
+		return ctx.Err()
+	})
+	var got [][]int
+	Batch(src, 3).Sink(func(b []int) { got = append(got, b) })
+
+	<-emitted
+	p.Stop()
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Wait после Stop вернул %v", err)
+	}
+	if want := [][]int{{1, 2, 3}, {4, 5}}; !slices.EqualFunc(got, want, slices.Equal[[]int]) {
+		t.Fatalf("получено %v, ожидалось %v", got, want)
+	}
+	if !p.Interrupted() {
+		t.Fatal("после Stop Interrupted() = false")
+	}
+}
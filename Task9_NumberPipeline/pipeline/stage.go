@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+)
+
+// stageConfig - настройки одного этапа
+type stageConfig struct {
+	name    string             // имя этапа в ошибках и статистике
+	buffer  int                // ёмкость выходного канала этапа
+	workers int                // количество параллельных копий этапа
+	ordered bool               // сохранять ли порядок входных элементов при workers > 1
+	policy  ErrorPolicy        // реакция на ошибку функции этапа
+	errSink chan<- *StageError // канал для ошибок при политике ToSink
+}
+
+// StageOption настраивает отдельный этап конвейера
+type StageOption func(*stageConfig)
+
+func newStageConfig(opts []StageOption) stageConfig {
+	cfg := stageConfig{workers: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// Name задаёт имя этапа, которое попадает в StageError и статистику (Stats, Diagnose)
+func Name(name string) StageOption {
+	return func(c *stageConfig) {
+		c.name = name
+	}
+}
+
+// Workers запускает n параллельных копий этапа: элементы входного канала раздаются копиям (fan-out),
+// а их результаты сливаются в один выходной канал (fan-in). без Ordered порядок на выходе не гарантируется
+func Workers(n int) StageOption {
+	return func(c *stageConfig) {
+		c.workers = max(n, 1)
+	}
+}
+
+// Ordered сохраняет порядок входных элементов на выходе параллельного этапа.
+// результаты, готовые раньше своей очереди, ждут в буфере; в работе одновременно не больше 2*n элементов
+func Ordered() StageOption {
+	return func(c *stageConfig) {
+		c.ordered = true
+	}
+}
+
+// Buffer задаёт ёмкость выходного канала этапа (по умолчанию 0 - небуферизованный канал).
+// буфер сглаживает неравномерную скорость соседних этапов; заполненность видна в StageStats.Queued
+func Buffer(n int) StageOption {
+	return func(c *stageConfig) {
+		c.buffer = max(n, 0)
+	}
+}
+
+// stage - данные этапа: настройки, имя и счётчики статистики
+type stage[T any] struct {
+	p     *Pipeline
+	cfg   stageConfig
+	name  string
+	stats *stageStats
+}
+
+// newStage создаёт описание этапа и регистрирует его статистику в конвейере
+func newStage[T any](p *Pipeline, opts []StageOption, kind string) *stage[T] {
+	cfg := newStageConfig(opts)
+	name := p.stageName(cfg, kind)
+	return &stage[T]{p: p, cfg: cfg, name: name, stats: p.register(name, cfg)}
+}
+
+// output создаёт выходной канал этапа с ёмкостью из опции Buffer
+func output[U any](st *stageStats, size int) chan U {
+	out := make(chan U, size)
+	st.queued = func() int { return len(out) }
+	return out
+}
+
+// sendTimed - send с учётом отправленных элементов и времени, которое этап провёл в ожидании отправки
+// (следующий этап не успевает забирать элементы)
+func sendTimed[T any](st *stageStats, ctx context.Context, ch chan<- T, v T) bool {
+	ok := waitSend(st, ctx, ch, v)
+	if ok {
+		st.out.Add(1)
+	}
+	return ok
+}
+
+// recvTimed - recv с учётом принятых элементов и времени, которое этап провёл в ожидании входа
+// (предыдущий этап не успевает поставлять элементы)
+func recvTimed[T any](st *stageStats, ctx context.Context, ch <-chan T) (T, bool) {
+	v, ok := waitRecv(st, ctx, ch)
+	if ok {
+		st.in.Add(1)
+	}
+	return v, ok
+}
+
+// waitSend - send, который только учитывает время блокировки (для внутренних каналов этапа)
+func waitSend[T any](st *stageStats, ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v: // быстрый путь без блокировки - время не замеряем
+		return true
+	default:
+	}
+	start := time.Now()
+	ok := send(ctx, ch, v)
+	st.sendBlocked.Add(int64(time.Since(start)))
+	return ok
+}
+
+// waitRecv - recv, который только учитывает время блокировки (для внутренних каналов этапа)
+func waitRecv[T any](st *stageStats, ctx context.Context, ch <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-ch:
+		return v, ok
+	default:
+	}
+	start := time.Now()
+	v, ok = recv(ctx, ch)
+	st.recvBlocked.Add(int64(time.Since(start)))
+	return v, ok
+}
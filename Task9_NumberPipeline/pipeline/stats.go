@@ -0,0 +1,116 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// stageStats - счётчики одного этапа; обновляются горутинами этапа
+type stageStats struct {
+	name    string
+	workers int
+	buffer  int
+	queued  func() int // текущая заполненность выходного канала; nil у приёмника
+
+	in, out     atomic.Int64 // принято и отправлено элементов
+	active      atomic.Int64 // суммарное время жизни горутин этапа, нс
+	recvBlocked atomic.Int64 // суммарное ожидание входа, нс
+	sendBlocked atomic.Int64 // суммарное ожидание отправки, нс
+}
+
+// track учитывает время жизни одной горутины этапа: defer st.track()()
+func (st *stageStats) track() func() {
+	start := time.Now()
+	return func() { st.active.Add(int64(time.Since(start))) }
+}
+
+// register добавляет статистику нового этапа в конвейер (в порядке создания этапов)
+func (p *Pipeline) register(name string, cfg stageConfig) *stageStats {
+	st := &stageStats{name: name, workers: cfg.workers, buffer: cfg.buffer}
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	p.stats = append(p.stats, st)
+	return st
+}
+
+// StageStats - снимок статистики этапа.
+// время суммируется по всем копиям этапа (Workers), поэтому для сравнения этапов удобнее доли (Utilization)
+type StageStats struct {
+	Name        string
+	Workers     int
+	Buffer      int           // ёмкость выходного канала
+	Queued      int           // элементов в выходном канале в момент снимка
+	In, Out     int64         // принято и отправлено элементов
+	Active      time.Duration // суммарное время работы горутин этапа
+	RecvBlocked time.Duration // ожидание входа: предыдущий этап не успевает
+	SendBlocked time.Duration // ожидание отправки: следующий этап не успевает (backpressure)
+	Busy        time.Duration // собственная работа этапа: Active - RecvBlocked - SendBlocked
+	Utilization float64       // доля Busy в Active, от 0 до 1
+}
+
+// Stats возвращает снимок статистики всех этапов в порядке их создания.
+// снимок можно брать и во время работы конвейера; Active незавершённых горутин учитывается после их выхода
+func (p *Pipeline) Stats() []StageStats {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	res := make([]StageStats, 0, len(p.stats))
+	for _, st := range p.stats {
+		s := StageStats{
+			Name:        st.name,
+			Workers:     st.workers,
+			Buffer:      st.buffer,
+			In:          st.in.Load(),
+			Out:         st.out.Load(),
+			Active:      time.Duration(st.active.Load()),
+			RecvBlocked: time.Duration(st.recvBlocked.Load()),
+			SendBlocked: time.Duration(st.sendBlocked.Load()),
+		}
+		if st.queued != nil {
+			s.Queued = st.queued()
+		}
+		s.Busy = max(s.Active-s.RecvBlocked-s.SendBlocked, 0)
+		if s.Active > 0 {
+			s.Utilization = float64(s.Busy) / float64(s.Active)
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// Bottleneck возвращает узкое место конвейера - этап с наибольшей долей собственной работы.
+// остальные этапы в это время в основном ждут: те, что до него, - отправки, те, что после, - входа
+func (p *Pipeline) Bottleneck() (StageStats, bool) {
+	var (
+		best  StageStats
+		found bool
+	)
+	for _, s := range p.Stats() {
+		if s.Active > 0 && (!found || s.Utilization > best.Utilization) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// Diagnose печатает в w таблицу статистики этапов и узкое место конвейера
+func (p *Pipeline) Diagnose(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "этап\tкопий\tбуфер\tв буфере\tпринято\tотправлено\tработа\tждал вход\tждал отправку\tзагрузка")
+	for _, s := range p.Stats() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%v\t%v\t%v\t%.0f%%\n",
+			s.Name, s.Workers, s.Buffer, s.Queued, s.In, s.Out,
+			s.Busy.Round(time.Microsecond), s.RecvBlocked.Round(time.Microsecond), s.SendBlocked.Round(time.Microsecond),
+			s.Utilization*100)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if b, ok := p.Bottleneck(); ok {
+		_, err := fmt.Fprintf(w, "узкое место: %s (собственная работа %.0f%% времени)\n", b.Name, b.Utilization*100)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// медленный средний этап - узкое место: соседи до него ждут отправки, после него - входа
+func TestBottleneckSlowMiddle(t *testing.T) {
+	const n = 30
+	items := make([]int, n)
+	for i := range items {
+		items[i] = i
+	}
+	p := New(context.Background())
+	src := FromSlice(p, items, Name("gen"))
+	fast := Map(src, func(_ context.Context, v int) int { return v + 1 }, Name("fast"))
+	slow := Map(fast, func(_ context.Context, v int) int {
+		time.Sleep(2 * time.Millisecond)
+		return v
+	}, Name("slow"))
+	after := Map(slow, func(_ context.Context, v int) int { return v * 2 }, Name("after"))
+	after.Sink(func(int) {})
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, ok := p.Bottleneck()
+	if !ok || b.Name != "slow" {
+		t.Fatalf("узкое место %q (%v), ожидалось slow; статистика: %+v", b.Name, ok, p.Stats())
+	}
+	if b.In != n || b.Out != n {
+		t.Fatalf("slow: принято %d, отправлено %d, ожидалось по %d", b.In, b.Out, n)
+	}
+	for _, s := range p.Stats() {
+		switch s.Name {
+		case "fast":
+			if s.SendBlocked < s.Busy {
+				t.Errorf("fast ждал отправки %v при собственной работе %v - перед узким местом ждут отправки", s.SendBlocked, s.Busy)
+			}
+		case "after":
+			if s.RecvBlocked < s.Busy {
+				t.Errorf("after ждал входа %v при собственной работе %v - после узкого места ждут входа", s.RecvBlocked, s.Busy)
+			}
+		}
+	}
+
+	var out strings.Builder
+	if err := p.Diagnose(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "узкое место: slow ") {
+		t.Fatalf("Diagnose не назвал slow узким местом:\n%s", out.String())
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != 7 { // заголовок, 5 этапов и итог
+		t.Fatalf("в выводе Diagnose %d строк, ожидалось 7:\n%s", lines, out.String())
+	}
+}
@@ -0,0 +1,256 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/numeric"
+)
+
+// оконные этапы группируют элементы потока в окна: по количеству (TumblingCount, SlidingCount),
+// по времени поступления (TumblingTime, SlidingTime) или по паузам между элементами (Session).
+// время - момент, когда элемент пришёл в этап (processing time), а не время события во входных данных.
+// пустые окна не выдаются. когда вход заканчивается (в том числе после Stop), незавершённое окно
+// выдаётся с Partial = true; при отмене конвейера (Cancel) окна просто отбрасываются
+
+// Window - элементы одного окна в порядке поступления и его границы
+type Window[T any] struct {
+	Items   []T
+	Start   time.Time // начало окна; для окон по количеству - время прихода первого элемента
+	End     time.Time // конец окна; для окон по количеству - время прихода последнего элемента
+	Partial bool      // окно выдано досрочно: вход закончился раньше, чем окно закрылось
+}
+
+// windowStage - общая часть оконных этапов: выходной канал и отправка окон
+type windowStage[T any] struct {
+	*stage[T]
+	out chan Window[T]
+}
+
+func newWindowStage[T any](s Stream[T], opts []StageOption) *windowStage[T] {
+	st := newStage[T](s.p, opts, "window")
+	return &windowStage[T]{stage: st, out: output[Window[T]](st.stats, st.cfg.buffer)}
+}
+
+func (w *windowStage[T]) emit(win Window[T]) bool {
+	return sendTimed(w.stats, w.p.ctx, w.out, win)
+}
+
+// flush выдаёт незавершённое окно в конце входа, если конвейер не отменён
+func (w *windowStage[T]) flush(win Window[T]) {
+	if len(win.Items) > 0 && w.p.ctx.Err() == nil {
+		win.Partial = true
+		w.emit(win)
+	}
+}
+
+// recvOrTick ждёт элемент или срабатывание таймера tick (nil - без таймера).
+// ok = false, если вход закончился или конвейер отменён; отличить их можно по p.ctx.Err()
+func (w *windowStage[T]) recvOrTick(ctx context.Context, in <-chan T, tick <-chan time.Time) (v T, now time.Time, ticked, ok bool) {
+	select {
+	case v, ok = <-in:
+		if ok {
+			w.stats.in.Add(1)
+		}
+		return v, time.Now(), false, ok
+	case <-tick:
+		// значение из канала таймера - момент срабатывания, и оно может быть раньше элементов,
+		// принятых, пока срабатывание ждало в канале; текущее время всегда не раньше них
+		return v, time.Now(), true, true
+	case <-ctx.Done():
+		return v, time.Time{}, false, false
+	}
+}
+
+// TumblingCount выдаёт непересекающиеся окна по size элементов
+func TumblingCount[T any](s Stream[T], size int, opts ...StageOption) Stream[Window[T]] {
+	return SlidingCount(s, size, size, opts...)
+}
+
+// SlidingCount выдаёт окна из последних size элементов через каждые step элементов:
+// при step < size окна перекрываются, при step = size это TumblingCount
+func SlidingCount[T any](s Stream[T], size, step int, opts ...StageOption) Stream[Window[T]] {
+	size, step = max(size, 1), max(step, 1)
+	w := newWindowStage(s, opts)
+	w.p.spawn(func() {
+		defer w.stats.track()()
+		defer close(w.out)
+		var (
+			items   []T
+			times   []time.Time // время прихода каждого элемента из items - для границ окна
+			seen    int         // сколько элементов пришло всего
+			next    int         // номер (с 0) первого элемента следующего окна
+			emitted int         // сколько элементов пришло к моменту выдачи последнего окна
+		)
+		// window возвращает окно из элементов буфера, начиная с элемента номер from
+		window := func(from int) Window[T] {
+			i := max(from-(seen-len(items)), 0)
+			return Window[T]{Items: slices.Clone(items[i:]), Start: times[i], End: times[len(times)-1]}
+		}
+		for {
+			v, now, _, ok := w.recvOrTick(w.p.ctx, s.ch, nil)
+			if !ok {
+				break
+			}
+			items, times = append(items, v), append(times, now)
+			if len(items) > size { // храним только последние size элементов
+				items, times = items[1:], times[1:]
+			}
+			seen++
+			if seen == next+size {
+				if !w.emit(window(next)) {
+					return
+				}
+				next += step
+				emitted = seen
+			}
+		}
+		// остаток - начало следующего окна, если в нём есть элементы, ещё не попавшие ни в одно окно
+		if seen > emitted && seen > next {
+			w.flush(window(next))
+		}
+	})
+	return Stream[Window[T]]{p: w.p, ch: w.out}
+}
+
+// TumblingTime выдаёт непересекающиеся окна длительностью d, отсчитываемые от запуска этапа
+func TumblingTime[T any](s Stream[T], d time.Duration, opts ...StageOption) Stream[Window[T]] {
+	return SlidingTime(s, d, d, opts...)
+}
+
+// SlidingTime каждые step выдаёт окно из элементов, пришедших за последние size:
+// при step < size окна перекрываются, при step = size это TumblingTime.
+// окно начинается за size - step до конца предыдущего: если тикер запоздал, окно длиннее size,
+// но элемент не пропадает, не попав ни в одно окно
+func SlidingTime[T any](s Stream[T], size, step time.Duration, opts ...StageOption) Stream[Window[T]] {
+	size, step = max(size, time.Millisecond), max(step, time.Millisecond)
+	w := newWindowStage(s, opts)
+	w.p.spawn(func() {
+		defer w.stats.track()()
+		defer close(w.out)
+		ticker := time.NewTicker(step)
+		defer ticker.Stop()
+		var (
+			items    []T
+			times    []time.Time
+			lastEmit = time.Now() // конец последнего выданного окна
+		)
+		// evict убирает элементы, пришедшие не позже from (окно - полуинтервал (from, to])
+		evict := func(from time.Time) {
+			i := 0
+			for i < len(times) && !times[i].After(from) {
+				i++
+			}
+			items, times = items[i:], times[i:]
+		}
+		for {
+			v, now, ticked, ok := w.recvOrTick(w.p.ctx, s.ch, ticker.C)
+			if !ok {
+				break
+			}
+			if !ticked {
+				items, times = append(items, v), append(times, now)
+				continue
+			}
+			start := lastEmit.Add(step - size)
+			evict(start)
+			lastEmit = now
+			if len(items) > 0 {
+				if !w.emit(Window[T]{Items: slices.Clone(items), Start: start, End: now}) {
+					return
+				}
+			}
+		}
+		// отдаём остаток, только если после последнего окна пришли новые элементы
+		if n := len(times); n > 0 && times[n-1].After(lastEmit) {
+			start := lastEmit.Add(step - size)
+			evict(start)
+			w.flush(Window[T]{Items: items, Start: start, End: time.Now()})
+		}
+	})
+	return Stream[Window[T]]{p: w.p, ch: w.out}
+}
+
+// Session выдаёт сессии - группы элементов, между которыми проходит меньше gap;
+// сессия закрывается, когда после последнего элемента gap прошло без новых
+func Session[T any](s Stream[T], gap time.Duration, opts ...StageOption) Stream[Window[T]] {
+	gap = max(gap, time.Millisecond)
+	w := newWindowStage(s, opts)
+	w.p.spawn(func() {
+		defer w.stats.track()()
+		defer close(w.out)
+		// таймер не перезапускается на каждый элемент: когда он срабатывает, проверяем время последнего
+		// элемента и, если сессия ещё идёт, заводим его на оставшееся время
+		timer := time.NewTimer(gap)
+		defer timer.Stop()
+		var session Window[T]
+		for {
+			v, now, ticked, ok := w.recvOrTick(w.p.ctx, s.ch, timer.C)
+			if !ok {
+				break
+			}
+			if !ticked {
+				if len(session.Items) == 0 {
+					session.Start = now
+				}
+				session.Items = append(session.Items, v)
+				session.End = now
+				continue
+			}
+			if len(session.Items) == 0 {
+				timer.Reset(gap)
+				continue
+			}
+			if idle := now.Sub(session.End); idle < gap {
+				timer.Reset(max(gap-idle, time.Millisecond))
+				continue
+			}
+			if !w.emit(session) {
+				return
+			}
+			session = Window[T]{}
+			timer.Reset(gap)
+		}
+		w.flush(session)
+	})
+	return Stream[Window[T]]{p: w.p, ch: w.out}
+}
+
+// Summary - агрегаты по одному окну
+type Summary[T any] struct {
+	Start, End time.Time
+	Partial    bool
+	Count      int
+	Sum        T
+	Min        T
+	Max        T
+	Mean       T // Sum / Count в арифметике a: в целых режимах - с отбрасыванием дробной части
+}
+
+// Aggregate считает по каждому окну количество, сумму, минимум, максимум и среднее в арифметике a.
+// ошибка арифметики (например, переполнение суммы в режиме int) обрабатывается политикой этапа (OnError)
+func Aggregate[T any](s Stream[Window[T]], a numeric.Arith[T], opts ...StageOption) Stream[Summary[T]] {
+	return MapErr(s, func(_ context.Context, w Window[T]) (Summary[T], error) {
+		sum := Summary[T]{Start: w.Start, End: w.End, Partial: w.Partial, Count: len(w.Items), Sum: a.FromInt(0)}
+		for i, v := range w.Items {
+			var err error
+			if sum.Sum, err = a.Add(sum.Sum, v); err != nil {
+				return sum, err
+			}
+			if i == 0 || a.Cmp(v, sum.Min) < 0 {
+				sum.Min = v
+			}
+			if i == 0 || a.Cmp(v, sum.Max) > 0 {
+				sum.Max = v
+			}
+		}
+		if sum.Count > 0 {
+			var err error
+			if sum.Mean, err = a.Quo(sum.Sum, a.FromInt(int64(sum.Count))); err != nil {
+				return sum, err
+			}
+		}
+		return sum, nil
+	}, append([]StageOption{Name("aggregate")}, opts...)...)
+}
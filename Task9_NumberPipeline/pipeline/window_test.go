@@ -0,0 +1,210 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/numeric"
+)
+
+// collectWindows собирает элементы окон и отметки Partial
+func collectWindows(t *testing.T, s Stream[Window[int]]) ([][]int, []bool) {
+	t.Helper()
+	var items [][]int
+	var partial []bool
+	err := s.ForEach(func(w Window[int]) error {
+		if w.End.Before(w.Start) {
+			t.Errorf("окно %v: конец %v раньше начала %v", w.Items, w.End, w.Start)
+		}
+		items, partial = append(items, w.Items), append(partial, w.Partial)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items, partial
+}
+
+// seq возвращает числа 1..n
+func seq(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i + 1
+	}
+	return s
+}
+
+// окна по количеству: непересекающиеся, перекрывающиеся и с пропусками (step > size);
+// остаток выдаётся как Partial, только если в нём есть элементы, не попавшие ни в одно окно
+func TestCountWindows(t *testing.T) {
+	cases := []struct {
+		name        string
+		n           int
+		size, step  int
+		want        [][]int
+		wantPartial []bool
+	}{
+		{"tumbling", 7, 3, 3, [][]int{{1, 2, 3}, {4, 5, 6}, {7}}, []bool{false, false, true}},
+		{"tumbling без остатка", 6, 3, 3, [][]int{{1, 2, 3}, {4, 5, 6}}, []bool{false, false}},
+		{"перекрывающиеся", 5, 3, 1, [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}, []bool{false, false, false}},
+		{"перекрывающиеся с остатком", 7, 4, 2, [][]int{{1, 2, 3, 4}, {3, 4, 5, 6}, {5, 6, 7}}, []bool{false, false, true}},
+		{"step > size", 8, 2, 3, [][]int{{1, 2}, {4, 5}, {7, 8}}, []bool{false, false, false}},
+		{"step > size, остаток в пропуске", 6, 2, 3, [][]int{{1, 2}, {4, 5}}, []bool{false, false}},
+		{"step > size, неполное окно", 7, 2, 3, [][]int{{1, 2}, {4, 5}, {7}}, []bool{false, false, true}},
+		{"вход короче окна", 2, 5, 5, [][]int{{1, 2}}, []bool{true}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := New(context.Background())
+			got, partial := collectWindows(t, SlidingCount(FromSlice(p, seq(c.n)), c.size, c.step))
+			if !slices.EqualFunc(got, c.want, slices.Equal[[]int]) || !slices.Equal(partial, c.wantPartial) {
+				t.Fatalf("окна %v (partial %v), ожидалось %v (%v)", got, partial, c.want, c.wantPartial)
+			}
+		})
+	}
+}
+
+// после Stop незавершённое окно выдаётся с Partial, после Cancel - отбрасывается
+func TestWindowStopAndCancel(t *testing.T) {
+	for _, cancel := range []bool{false, true} {
+		p := New(context.Background())
+		emitted := make(chan struct{})
+		src := Source(p, func(ctx context.Context, emit func(int) bool) error {
+			for i := 1; i <= 5; i++ {
+				if !emit(i) {
+					return nil
+				}
+			}
+			close(emitted) // окно [4 5] собрано, но не закрыто
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		windows := TumblingCount(src, 3)
+		go func() {
+			<-emitted
+			if cancel {
+				p.Cancel()
+			} else {
+				p.Stop()
+			}
+		}()
+		got, partial := collectWindows(t, windows)
+
+		want, wantPartial := [][]int{{1, 2, 3}, {4, 5}}, []bool{false, true}
+		if cancel {
+			want, wantPartial = want[:1], wantPartial[:1]
+		}
+		if !slices.EqualFunc(got, want, slices.Equal[[]int]) || !slices.Equal(partial, wantPartial) {
+			t.Fatalf("cancel=%v: окна %v (partial %v), ожидалось %v (%v)", cancel, got, partial, want, wantPartial)
+		}
+	}
+}
+
+// окна по времени: пока окно не закрылось, элементы копятся; новое окно содержит только элементы за последние size
+func TestSlidingTime(t *testing.T) {
+	// окно длиной в час выдаётся каждые 20ms: очередное окно содержит все элементы
+	in := make(chan int)
+	p := New(context.Background())
+	windows := SlidingTime(FromChan(p, in), time.Hour, 20*time.Millisecond).Chan()
+	in <- 1
+	if w := <-windows; !slices.Equal(w.Items, []int{1}) || w.Partial || w.End.Sub(w.Start) < time.Hour-time.Second {
+		t.Fatalf("первое окно %+v", w)
+	}
+	in <- 2
+	for w := range windows {
+		if len(w.Items) == 1 { // окно могло закрыться раньше, чем 2 дошло до этапа
+			continue
+		}
+		if !slices.Equal(w.Items, []int{1, 2}) {
+			t.Fatalf("перекрывающееся окно %v, ожидалось [1 2]", w.Items)
+		}
+		break
+	}
+	p.Cancel()
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// непересекающиеся окна по 20ms: элемент, пришедший заметно позже, попадает в окно без старых элементов
+	in = make(chan int)
+	p = New(context.Background())
+	windows = TumblingTime(FromChan(p, in), 20*time.Millisecond).Chan()
+	in <- 1
+	if w := <-windows; !slices.Equal(w.Items, []int{1}) {
+		t.Fatalf("первое окно %v", w.Items)
+	}
+	time.Sleep(100 * time.Millisecond)
+	in <- 2
+	if w := <-windows; !slices.Equal(w.Items, []int{2}) {
+		t.Fatalf("окно после паузы %v, ожидалось [2]", w.Items)
+	}
+
+	// элементы после последнего окна выдаются при конце входа как Partial
+	in <- 3
+	close(in)
+	var last Window[int]
+	for w := range windows {
+		last = w
+	}
+	if err := p.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if !last.Partial || !slices.Contains(last.Items, 3) {
+		t.Fatalf("последнее окно %+v, ожидалось неполное окно с 3", last)
+	}
+}
+
+// сессия закрывается паузой не короче gap; незавершённая сессия выдаётся при конце входа
+func TestSession(t *testing.T) {
+	in := make(chan int)
+	p := New(context.Background())
+	sessions := Session(FromChan(p, in), 50*time.Millisecond)
+	go func() {
+		in <- 1
+		in <- 2
+		time.Sleep(200 * time.Millisecond)
+		in <- 3
+		close(in)
+	}()
+	got, partial := collectWindows(t, sessions)
+	if want := [][]int{{1, 2}, {3}}; !slices.EqualFunc(got, want, slices.Equal[[]int]) || !slices.Equal(partial, []bool{false, true}) {
+		t.Fatalf("сессии %v (partial %v), ожидалось %v ([false true])", got, partial, want)
+	}
+}
+
+// Aggregate считает количество, сумму, минимум, максимум и среднее по окну; переполнение суммы - ошибка этапа
+func TestAggregate(t *testing.T) {
+	p := New(context.Background())
+	windows := TumblingCount(FromSlice(p, []int{5, -3, 10, 7, 8}), 3)
+	var got []Summary[int]
+	err := Aggregate(windows, numeric.Int{}).ForEach(func(s Summary[int]) error {
+		got = append(got, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type agg struct {
+		count, sum, min, max, mean int
+		partial                    bool
+	}
+	var have []agg
+	for _, s := range got {
+		have = append(have, agg{s.Count, s.Sum, s.Min, s.Max, s.Mean, s.Partial})
+	}
+	if want := []agg{{3, 12, -3, 10, 4, false}, {2, 15, 7, 8, 7, true}}; !slices.Equal(have, want) {
+		t.Fatalf("агрегаты %v, ожидалось %v", have, want)
+	}
+
+	p = New(context.Background())
+	windows = TumblingCount(FromSlice(p, []int{math.MaxInt, 1}), 2)
+	err = Aggregate(windows, numeric.Int{}).ForEach(func(Summary[int]) error { return nil })
+	var se *StageError
+	if !errors.Is(err, numeric.ErrOverflow) || !errors.As(err, &se) || se.Stage != "aggregate" {
+		t.Fatalf("переполнение суммы: %v", err)
+	}
+}
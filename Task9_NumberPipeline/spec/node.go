@@ -0,0 +1,182 @@
+// Package spec - декларативное описание конвейера чисел (L1.9) в YAML или JSON:
+// источник, список этапов из реестра с параметрами и приёмник. описание проверяется до запуска,
+// а каждая ошибка проверки указывает строку конфигурации, в которой она найдена
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kind - вид узла конфигурации
+type Kind int
+
+const (
+	Scalar Kind = iota
+	Mapping
+	Sequence
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Mapping:
+		return "объект"
+	case Sequence:
+		return "список"
+	default:
+		return "значение"
+	}
+}
+
+// Node - узел разобранной конфигурации с номером строки, в которой он начинается.
+// YAML и JSON разбираются в одинаковое дерево, поэтому проверка конфигурации от формата не зависит
+type Node struct {
+	Kind   Kind
+	Line   int
+	Value  string           // для Scalar
+	Keys   []string         // ключи Mapping в порядке появления
+	Fields map[string]*Node // значения Mapping
+	Items  []*Node          // элементы Sequence
+}
+
+// Get возвращает значение ключа объекта (nil, если ключа нет или узел не объект)
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != Mapping {
+		return nil
+	}
+	return n.Fields[key]
+}
+
+func newMapping(line int) *Node {
+	return &Node{Kind: Mapping, Line: line, Fields: make(map[string]*Node)}
+}
+
+// set добавляет ключ в объект; повторный ключ - ошибка
+func (n *Node) set(key string, v *Node, line int) error {
+	if _, dup := n.Fields[key]; dup {
+		return &Error{Line: line, Msg: fmt.Sprintf("ключ %q указан повторно", key)}
+	}
+	n.Keys = append(n.Keys, key)
+	n.Fields[key] = v
+	return nil
+}
+
+// Error - ошибка разбора или проверки конфигурации с номером строки
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	file := e.File
+	if file == "" {
+		file = "конфигурация"
+	}
+	if e.Line <= 0 {
+		return fmt.Sprintf("%s: %s", file, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", file, e.Line, e.Msg)
+}
+
+// errorf создаёт ошибку конфигурации для узла n
+func errorf(n *Node, format string, args ...any) error {
+	line := 0
+	if n != nil {
+		line = n.Line
+	}
+	return &Error{Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ParseJSON разбирает JSON в дерево узлов, запоминая строку каждого значения
+func ParseJSON(data []byte) (*Node, error) {
+	// начала строк - чтобы переводить смещение токена в номер строки
+	starts := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	// InputOffset указывает на конец последнего токена; пропускаем пробелы и разделители после него,
+	// чтобы получить строку начала следующего значения
+	lineAt := func() int {
+		off := int(dec.InputOffset())
+		for off < len(data) && strings.IndexByte(" \t\r\n,:", data[off]) >= 0 {
+			off++
+		}
+		return sort.Search(len(starts), func(i int) bool { return starts[i] > off })
+	}
+
+	var parse func() (*Node, error)
+	parse = func() (*Node, error) {
+		line := lineAt()
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonError(err, line)
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			switch t {
+			case '{':
+				n := newMapping(line)
+				for dec.More() {
+					keyLine := lineAt()
+					keyTok, err := dec.Token()
+					if err != nil {
+						return nil, jsonError(err, keyLine)
+					}
+					v, err := parse()
+					if err != nil {
+						return nil, err
+					}
+					if err := n.set(keyTok.(string), v, keyLine); err != nil {
+						return nil, err
+					}
+				}
+				_, err := dec.Token() // '}'
+				return n, jsonError(err, lineAt())
+			case '[':
+				n := &Node{Kind: Sequence, Line: line}
+				for dec.More() {
+					v, err := parse()
+					if err != nil {
+						return nil, err
+					}
+					n.Items = append(n.Items, v)
+				}
+				_, err := dec.Token() // ']'
+				return n, jsonError(err, lineAt())
+			}
+		case nil:
+			return &Node{Kind: Scalar, Line: line, Value: ""}, nil
+		}
+		return &Node{Kind: Scalar, Line: line, Value: fmt.Sprint(tok)}, nil
+	}
+
+	root, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, &Error{Line: lineAt(), Msg: "лишние данные после конца JSON"}
+	}
+	return root, nil
+}
+
+// jsonError добавляет к ошибке encoding/json номер строки
+func jsonError(err error, line int) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return &Error{Line: line, Msg: "некорректный JSON: " + err.Error()}
+}
@@ -0,0 +1,125 @@
+package spec
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/numeric"
+)
+
+// Params - параметры этапа из конфигурации (например, {factor: 2} у multiply).
+// фабрика этапа читает нужные ей ключи; ключи, которые никто не прочитал, считаются опечаткой
+type Params struct {
+	node  *Node
+	arith numeric.Arith[any]
+	used  map[string]bool
+}
+
+func newParams(n *Node, arith numeric.Arith[any]) *Params {
+	return &Params{node: n, arith: arith, used: make(map[string]bool)}
+}
+
+// Arith возвращает арифметику режима конфигурации (mode): этапы считают только через неё
+func (p *Params) Arith() numeric.Arith[any] {
+	return p.arith
+}
+
+// Has сообщает, задан ли параметр
+func (p *Params) Has(key string) bool {
+	return p.node.Get(key) != nil
+}
+
+// get возвращает скалярное значение параметра и отмечает его как прочитанное
+func (p *Params) get(key string) (*Node, error) {
+	p.used[key] = true
+	v := p.node.Get(key)
+	if v != nil && v.Kind != Scalar {
+		return nil, errorf(v, "параметр %s: ожидается значение, получен %s", key, v.Kind)
+	}
+	return v, nil
+}
+
+// Int возвращает целый параметр или def, если он не задан
+func (p *Params) Int(key string, def int) (int, error) {
+	v, err := p.get(key)
+	if err != nil || v == nil {
+		return def, err
+	}
+	n, err := strconv.Atoi(v.Value)
+	if err != nil {
+		return def, errorf(v, "параметр %s: %q не является целым числом", key, v.Value)
+	}
+	return n, nil
+}
+
+// Number возвращает числовой параметр в режиме конфигурации (например, factor: 1.5 в режиме rat)
+// или def, разобранный тем же способом, если параметр не задан
+func (p *Params) Number(key, def string) (any, error) {
+	v, err := p.get(key)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return p.arith.Parse(def)
+	}
+	n, err := p.arith.Parse(v.Value)
+	if err != nil {
+		return nil, errorf(v, "параметр %s: %q не является числом в режиме %s", key, v.Value, p.arith.Name())
+	}
+	return n, nil
+}
+
+// Bool возвращает логический параметр (true/false) или def, если он не задан
+func (p *Params) Bool(key string, def bool) (bool, error) {
+	v, err := p.get(key)
+	if err != nil || v == nil {
+		return def, err
+	}
+	b, err := strconv.ParseBool(v.Value)
+	if err != nil {
+		return def, errorf(v, "параметр %s: ожидается true или false, получено %q", key, v.Value)
+	}
+	return b, nil
+}
+
+// String возвращает строковый параметр или def, если он не задан
+func (p *Params) String(key, def string) (string, error) {
+	v, err := p.get(key)
+	if err != nil || v == nil {
+		return def, err
+	}
+	return v.Value, nil
+}
+
+// Required возвращает ошибку, если параметр не задан
+func (p *Params) Required(key string) error {
+	if !p.Has(key) {
+		return errorf(p.node, "не задан обязательный параметр %s", key)
+	}
+	return nil
+}
+
+// unused возвращает ошибки для параметров, которые фабрика этапа не прочитала
+func (p *Params) unused(stage string) []error {
+	if p.node == nil {
+		return nil
+	}
+	var errs []error
+	for _, key := range p.node.Keys {
+		if !p.used[key] {
+			errs = append(errs, errorf(p.node.Fields[key], "неизвестный параметр %s у этапа %s", key, stage))
+		}
+	}
+	return errs
+}
+
+// unknownKeys возвращает ключи, не входящие в список допустимых, в порядке появления
+func unknownKeys(n *Node, allowed ...string) []string {
+	var unknown []string
+	for _, key := range n.Keys {
+		if !slices.Contains(allowed, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,183 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// StageFunc - функция этапа: по одному числу возвращает ноль, одно или несколько чисел
+// (фильтр возвращает пустой срез, чтобы отбросить число). значения - в арифметике Params.Arith()
+type StageFunc func(ctx context.Context, v any) ([]any, error)
+
+// Factory создаёт функцию этапа по его параметрам из конфигурации.
+// ошибки параметров удобно возвращать от методов Params - они уже содержат номер строки
+type Factory func(params *Params) (StageFunc, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register добавляет этап в реестр под именем name, после чего его можно указывать в конфигурации.
+// повторная регистрация того же имени - ошибка программиста, поэтому паникует (как sql.Register)
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if f == nil {
+		panic("spec: Register с nil фабрикой для " + name)
+	}
+	if _, dup := registry[name]; dup {
+		panic("spec: этап " + name + " уже зарегистрирован")
+	}
+	registry[name] = f
+}
+
+// Stages возвращает отсортированные имена зарегистрированных этапов
+func Stages() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[name]
+	return f, ok
+}
+
+// встроенные этапы; все вычисления идут через params.Arith(), поэтому этапы работают в любом режиме
+func init() {
+	// multiply: {factor: 2} - умножает число на factor (по умолчанию 2, как в исходной задаче)
+	Register("multiply", func(params *Params) (StageFunc, error) {
+		a := params.Arith()
+		factor, err := params.Number("factor", "2")
+		if err != nil {
+			return nil, err
+		}
+		return func(_ context.Context, v any) ([]any, error) {
+			return one(a.Mul(v, factor))
+		}, nil
+	})
+
+	// add: {n: 10} - прибавляет к числу n
+	Register("add", func(params *Params) (StageFunc, error) {
+		a := params.Arith()
+		if err := params.Required("n"); err != nil {
+			return nil, err
+		}
+		n, err := params.Number("n", "0")
+		if err != nil {
+			return nil, err
+		}
+		return func(_ context.Context, v any) ([]any, error) {
+			return one(a.Add(v, n))
+		}, nil
+	})
+
+	// filter: {even: true, min: 0, max: 100} - пропускает только числа, подходящие под все условия;
+	// even и odd допустимы только в целых режимах (int, bigint)
+	Register("filter", func(params *Params) (StageFunc, error) {
+		a := params.Arith()
+		even, err := params.Bool("even", false)
+		if err != nil {
+			return nil, err
+		}
+		odd, err := params.Bool("odd", false)
+		if err != nil {
+			return nil, err
+		}
+		hasMin, hasMax := params.Has("min"), params.Has("max")
+		lo, err := params.Number("min", "0")
+		if err != nil {
+			return nil, err
+		}
+		hi, err := params.Number("max", "0")
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case (even || odd) && !a.Integer():
+			return nil, errorf(params.node, "even и odd допустимы только в режимах int и bigint, а не %s", a.Name())
+		case even && odd:
+			return nil, errorf(params.node, "even и odd одновременно не пропустят ни одного числа")
+		case !even && !odd && !hasMin && !hasMax:
+			return nil, errorf(params.node, "не задано ни одного условия (even, odd, min, max)")
+		case hasMin && hasMax && a.Cmp(lo, hi) > 0:
+			return nil, errorf(params.node.Get("min"), "min (%s) больше max (%s)", a.String(lo), a.String(hi))
+		}
+		two, zero := a.FromInt(2), a.FromInt(0)
+		return func(_ context.Context, v any) ([]any, error) {
+			if even || odd {
+				rem, err := a.Rem(v, two)
+				if err != nil {
+					return nil, err
+				}
+				if isEven := a.Cmp(rem, zero) == 0; isEven != even {
+					return nil, nil
+				}
+			}
+			if (hasMin && a.Cmp(v, lo) < 0) || (hasMax && a.Cmp(v, hi) > 0) {
+				return nil, nil
+			}
+			return []any{v}, nil
+		}, nil
+	})
+
+	// div: {by: 3} - деление на by (в целых режимах - с отбрасыванием дробной части);
+	// by: 0 отклоняется ещё при загрузке конфигурации
+	Register("div", func(params *Params) (StageFunc, error) {
+		a := params.Arith()
+		if err := params.Required("by"); err != nil {
+			return nil, err
+		}
+		by, err := params.Number("by", "1")
+		if err != nil {
+			return nil, err
+		}
+		if a.Cmp(by, a.FromInt(0)) == 0 {
+			return nil, errorf(params.node.Get("by"), "деление на 0")
+		}
+		return func(_ context.Context, v any) ([]any, error) {
+			return one(a.Quo(v, by))
+		}, nil
+	})
+
+	// repeat: {times: 2} - повторяет каждое число times раз
+	Register("repeat", func(params *Params) (StageFunc, error) {
+		times, err := params.Int("times", 2)
+		if err != nil {
+			return nil, err
+		}
+		if times < 0 {
+			return nil, errorf(params.node.Get("times"), "times должен быть не меньше 0, получено %d", times)
+		}
+		return func(_ context.Context, v any) ([]any, error) {
+			out := make([]any, times)
+			for i := range out {
+				out[i] = v
+			}
+			return out, nil
+		}, nil
+	})
+}
+
+// one оборачивает результат арифметической операции в результат этапа
+func one(v any, err error) ([]any, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []any{v}, nil
+}
+
+// stageUsage - строка со списком этапов для сообщений об ошибках
+func stageUsage() string {
+	return fmt.Sprint(Stages())
+}
@@ -0,0 +1,428 @@
+package spec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/numeric"
+	"github.com/Kras0Tanya/WB-L1/Task9_NumberPipeline/pipeline"
+)
+
+// Spec - проверенное описание конвейера, готовое к запуску.
+//
+// пример в YAML:
+//
+//	mode: rat                # int (по умолчанию), bigint, rat или float; для float - ещё precision: 256
+//	source:
+//	  input: data/*.txt.gz   # или numbers: [1, 2, 3, 4, 5]
+//	  sep: comma
+//	stages:
+//	  - multiply: {factor: 2}
+//	    workers: 3
+//	    ordered: true
+//	  - filter: {even: true}
+//	    on_error: skip
+//	sink: stdout             # или {file: out.txt}
+type Spec struct {
+	Arith  numeric.Arith[any] // арифметика режима mode
+	Source Source
+	Stages []Stage
+	Sink   Sink
+}
+
+// Source - откуда берутся числа: фиксированный список или файлы/stdin
+type Source struct {
+	Numbers []any           // значения в арифметике Spec.Arith
+	Input   *pipeline.Input // nil, если задан Numbers
+}
+
+// Stage - этап из реестра с параметрами и общими настройками
+type Stage struct {
+	Type    string // имя этапа в реестре
+	Name    string // имя в ошибках и статистике
+	Line    int
+	Workers int
+	Ordered bool
+	Buffer  int
+	Policy  pipeline.ErrorPolicy
+	fn      StageFunc
+}
+
+// Sink - куда выводятся результаты: stdout (Path пуст) или файл
+type Sink struct {
+	Path string
+}
+
+// stageOptions - общие настройки, которые допустимы у любого этапа рядом с его именем
+var stageOptions = []string{"name", "workers", "ordered", "buffer", "on_error"}
+
+// Load читает описание конвейера из файла; формат определяется по расширению (.yaml, .yml или .json).
+// все найденные ошибки возвращаются вместе, каждая - с именем файла и номером строки
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var format string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		format = "yaml"
+	case ".json":
+		format = "json"
+	default:
+		return nil, fmt.Errorf("%s: неизвестный формат конфигурации %q (ожидается .yaml, .yml или .json)", path, ext)
+	}
+	s, err := Parse(data, format)
+	return s, withFile(err, path)
+}
+
+// withFile проставляет имя файла во все ошибки конфигурации внутри err
+func withFile(err error, path string) error {
+	if err == nil {
+		return nil
+	}
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else {
+		errs = []error{err}
+	}
+	for _, e := range errs {
+		var ce *Error
+		if errors.As(e, &ce) {
+			ce.File = path
+		}
+	}
+	return err
+}
+
+// Parse разбирает и проверяет описание конвейера в формате "yaml" или "json"
+func Parse(data []byte, format string) (*Spec, error) {
+	var (
+		root *Node
+		err  error
+	)
+	switch format {
+	case "yaml":
+		root, err = ParseYAML(data)
+	case "json":
+		root, err = ParseJSON(data)
+	default:
+		return nil, fmt.Errorf("неизвестный формат конфигурации %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Decode(root)
+}
+
+// Decode проверяет дерево конфигурации и строит по нему Spec.
+// проверка не останавливается на первой ошибке: возвращаются все найденные (errors.Join)
+func Decode(root *Node) (*Spec, error) {
+	if root.Kind != Mapping {
+		return nil, errorf(root, "ожидается объект с ключами source, stages и sink, получен %s", root.Kind)
+	}
+	var (
+		s    Spec
+		errs []error
+	)
+	for _, key := range unknownKeys(root, "mode", "precision", "source", "stages", "sink") {
+		errs = append(errs, errorf(root.Fields[key], "неизвестный ключ %s (допустимо: mode, precision, source, stages, sink)", key))
+	}
+
+	// режим разбирается первым: от него зависит, какие числа допустимы в source и параметрах этапов
+	arith, modeErrs := decodeMode(root)
+	errs = append(errs, modeErrs...)
+	if arith == nil {
+		return nil, errors.Join(errs...)
+	}
+	s.Arith = arith
+
+	if src := root.Get("source"); src == nil {
+		errs = append(errs, errorf(root, "не задан источник (source)"))
+	} else {
+		errs = append(errs, decodeSource(src, arith, &s.Source)...)
+	}
+
+	if stages := root.Get("stages"); stages != nil {
+		if stages.Kind != Sequence {
+			errs = append(errs, errorf(stages, "stages: ожидается список этапов, получен %s", stages.Kind))
+		} else {
+			for i, item := range stages.Items {
+				st, stErrs := decodeStage(item, i+1, arith)
+				errs = append(errs, stErrs...)
+				s.Stages = append(s.Stages, st)
+			}
+		}
+	}
+
+	if sink := root.Get("sink"); sink != nil {
+		errs = append(errs, decodeSink(sink, &s.Sink)...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &s, nil
+}
+
+// decodeMode разбирает mode и precision; без mode используется int
+func decodeMode(root *Node) (numeric.Arith[any], []error) {
+	mode, prec := root.Get("mode"), root.Get("precision")
+	var errs []error
+	name := "int"
+	if mode != nil {
+		if mode.Kind != Scalar {
+			return nil, []error{errorf(mode, "mode: ожидается %s, получен %s", numeric.Modes, mode.Kind)}
+		}
+		name = mode.Value
+	}
+	var bits uint64
+	if prec != nil {
+		var err error
+		switch bits, err = strconv.ParseUint(prec.Value, 10, 32); {
+		case name != "float":
+			errs = append(errs, errorf(prec, "precision имеет смысл только в режиме float"))
+		case prec.Kind != Scalar || err != nil || bits == 0:
+			errs = append(errs, errorf(prec, "precision: ожидается положительное число бит, получено %q", prec.Value))
+		}
+	}
+	arith, err := numeric.ByName(name, uint(bits))
+	if err != nil {
+		errs = append(errs, errorf(mode, "mode: %v", err))
+	}
+	return arith, errs
+}
+
+func decodeSource(n *Node, arith numeric.Arith[any], src *Source) []error {
+	if n.Kind != Mapping {
+		return []error{errorf(n, "source: ожидается объект с numbers или input, получен %s", n.Kind)}
+	}
+	var errs []error
+	for _, key := range unknownKeys(n, "numbers", "input", "sep") {
+		errs = append(errs, errorf(n.Fields[key], "неизвестный ключ source.%s (допустимо: numbers, input, sep)", key))
+	}
+	numbers, input := n.Get("numbers"), n.Get("input")
+	switch {
+	case numbers != nil && input != nil:
+		return append(errs, errorf(input, "source: numbers и input взаимоисключающие"))
+	case numbers == nil && input == nil:
+		return append(errs, errorf(n, "source: нужно задать numbers или input"))
+	case numbers != nil:
+		if n.Get("sep") != nil {
+			errs = append(errs, errorf(n.Get("sep"), "source.sep имеет смысл только вместе с input"))
+		}
+		if numbers.Kind != Sequence {
+			return append(errs, errorf(numbers, "source.numbers: ожидается список чисел, получен %s", numbers.Kind))
+		}
+		for _, item := range numbers.Items {
+			v, err := arith.Parse(item.Value)
+			if item.Kind != Scalar || err != nil {
+				errs = append(errs, errorf(item, "source.numbers: %q не является числом в режиме %s", item.Value, arith.Name()))
+				continue
+			}
+			src.Numbers = append(src.Numbers, v)
+		}
+		return errs
+	}
+
+	in := &pipeline.Input{}
+	switch input.Kind {
+	case Scalar:
+		in.Paths = []string{input.Value}
+	case Sequence:
+		for _, item := range input.Items {
+			if item.Kind != Scalar {
+				errs = append(errs, errorf(item, "source.input: ожидается путь, получен %s", item.Kind))
+				continue
+			}
+			in.Paths = append(in.Paths, item.Value)
+		}
+	default:
+		errs = append(errs, errorf(input, "source.input: ожидается путь или список путей, получен %s", input.Kind))
+	}
+	for i, path := range in.Paths {
+		if path == "" {
+			errs = append(errs, errorf(input, "source.input: пустой путь (%d-й)", i+1))
+		}
+	}
+	if sep := n.Get("sep"); sep != nil {
+		var err error
+		if in.Sep, err = pipeline.ParseSeparator(sep.Value); err != nil || sep.Kind != Scalar {
+			errs = append(errs, errorf(sep, "source.sep: неизвестный разделитель %q (допустимо: newline, comma, space)", sep.Value))
+		}
+	}
+	src.Input = in
+	return errs
+}
+
+// decodeStage разбирает элемент списка stages: "- multiply: {factor: 2}" с общими настройками рядом
+// или просто "- multiply", если параметры не нужны
+func decodeStage(n *Node, index int, arith numeric.Arith[any]) (Stage, []error) {
+	st := Stage{Line: n.Line, Workers: 1}
+	var (
+		params *Node
+		errs   []error
+	)
+	switch n.Kind {
+	case Scalar:
+		st.Type = n.Value
+	case Mapping:
+		for _, key := range n.Keys {
+			if isStageOption(key) {
+				continue
+			}
+			if st.Type != "" {
+				errs = append(errs, errorf(n.Fields[key], "этап #%d: лишний ключ %s - этап уже задан как %s", index, key, st.Type))
+				continue
+			}
+			st.Type, params = key, n.Fields[key]
+		}
+		errs = append(errs, decodeStageOptions(n, &st)...)
+	default:
+		return st, []error{errorf(n, "этап #%d: ожидается имя этапа или объект, получен %s", index, n.Kind)}
+	}
+	if st.Type == "" {
+		return st, append(errs, errorf(n, "этап #%d: не указано имя этапа (доступны: %s)", index, stageUsage()))
+	}
+	if st.Name == "" {
+		st.Name = fmt.Sprintf("%s#%d", st.Type, index)
+	}
+
+	factory, ok := lookup(st.Type)
+	if !ok {
+		at := n
+		if params != nil {
+			at = params
+		}
+		return st, append(errs, errorf(at, "неизвестный этап %q (доступны: %s)", st.Type, stageUsage()))
+	}
+	if params != nil && params.Kind != Mapping && !(params.Kind == Scalar && params.Value == "") {
+		return st, append(errs, errorf(params, "этап %s: параметры задаются объектом, например {ключ: значение}, получен %s", st.Type, params.Kind))
+	}
+	if params != nil && params.Kind != Mapping {
+		params = nil // "- multiply:" без значения - то же, что без параметров
+	}
+
+	// фабрика видит пустой объект вместо nil, чтобы ошибки "не задан параметр" указывали на строку этапа
+	if params == nil {
+		params = newMapping(n.Line)
+	}
+	ps := newParams(params, arith)
+	fn, err := factory(ps)
+	if err != nil {
+		return st, append(errs, err)
+	}
+	st.fn = fn
+	return st, append(errs, ps.unused(st.Type)...)
+}
+
+func isStageOption(key string) bool {
+	return slices.Contains(stageOptions, key)
+}
+
+// decodeStageOptions разбирает общие настройки этапа (name, workers, ordered, buffer, on_error)
+func decodeStageOptions(n *Node, st *Stage) []error {
+	var errs []error
+	ps := newParams(n, nil)
+	var err error
+	if st.Name, err = ps.String("name", ""); err != nil {
+		errs = append(errs, err)
+	}
+	if st.Workers, err = ps.Int("workers", 1); err != nil {
+		errs = append(errs, err)
+	} else if st.Workers < 1 {
+		errs = append(errs, errorf(n.Get("workers"), "workers должен быть не меньше 1, получено %d", st.Workers))
+	}
+	if st.Ordered, err = ps.Bool("ordered", false); err != nil {
+		errs = append(errs, err)
+	}
+	if st.Buffer, err = ps.Int("buffer", 0); err != nil {
+		errs = append(errs, err)
+	} else if st.Buffer < 0 {
+		errs = append(errs, errorf(n.Get("buffer"), "buffer должен быть не меньше 0, получено %d", st.Buffer))
+	}
+	policy, err := ps.String("on_error", "fail")
+	switch {
+	case err != nil:
+		errs = append(errs, err)
+	case policy == "fail":
+		st.Policy = pipeline.FailFast
+	case policy == "skip":
+		st.Policy = pipeline.Skip
+	default:
+		errs = append(errs, errorf(n.Get("on_error"), "on_error: неизвестная политика %q (допустимо: fail, skip)", policy))
+	}
+	return errs
+}
+
+func decodeSink(n *Node, sink *Sink) []error {
+	switch {
+	case n.Kind == Scalar && (n.Value == "stdout" || n.Value == "-" || n.Value == ""):
+		return nil
+	case n.Kind == Mapping:
+		var errs []error
+		for _, key := range unknownKeys(n, "file") {
+			errs = append(errs, errorf(n.Fields[key], "неизвестный ключ sink.%s (допустимо: file)", key))
+		}
+		file := n.Get("file")
+		if file == nil || file.Kind != Scalar || file.Value == "" {
+			return append(errs, errorf(n, "sink.file: ожидается путь к файлу"))
+		}
+		sink.Path = file.Value
+		return errs
+	}
+	return []error{errorf(n, "sink: ожидается stdout или {file: путь}")}
+}
+
+// Run собирает конвейер в p по описанию и выполняет его, выводя результаты по одному числу на строку
+// в файл приёмника или в stdout, если файл не задан
+func (s *Spec) Run(p *pipeline.Pipeline, stdout io.Writer) (err error) {
+	w := stdout
+	if s.Sink.Path != "" {
+		f, err := os.Create(s.Sink.Path)
+		if err != nil {
+			p.Cancel()
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	var stream pipeline.Stream[any]
+	if s.Source.Input != nil {
+		stream = pipeline.NumbersOf(p, *s.Source.Input, s.Arith.Parse)
+	} else {
+		stream = pipeline.FromSlice(p, s.Source.Numbers, pipeline.Name("numbers"))
+	}
+	for _, st := range s.Stages {
+		opts := []pipeline.StageOption{
+			pipeline.Name(st.Name), pipeline.Workers(st.Workers), pipeline.Buffer(st.Buffer), pipeline.OnError(st.Policy),
+		}
+		if st.Ordered {
+			opts = append(opts, pipeline.Ordered())
+		}
+		stream = pipeline.FlatMapErr(stream, st.fn, opts...)
+	}
+
+	err = stream.ForEach(func(v any) error {
+		_, err := fmt.Fprintln(bw, s.Arith.String(v))
+		return err
+	}, pipeline.Name("sink"))
+	if ferr := bw.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
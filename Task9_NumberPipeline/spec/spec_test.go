@@ -0,0 +1,96 @@
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Load проверяет описание целиком и возвращает все ошибки, каждую с именем файла и строкой
+func TestLoadReportsFileLine(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		file, src string
+		want      []string
+	}{
+		{
+			file: "bad.yaml",
+			src: `source:
+  numbers: [1, 2, x]
+stages:
+  - multiply: {factor: 2}
+    workers: 0
+  - nosuch
+  - add:
+      n: 1
+      extra: 2
+sink: stdout
+`,
+			want: []string{
+				`bad.yaml:2: source.numbers: "x" не является числом`,
+				"bad.yaml:5: workers должен быть не меньше 1",
+				`bad.yaml:6: неизвестный этап "nosuch"`,
+				"bad.yaml:9: неизвестный параметр extra у этапа add",
+			},
+		},
+		{
+			file: "bad.json",
+			src: `{
+  "source": {"numbers": [1]},
+  "stages": [
+    {"div": {}},
+    {"filter": {"even": "maybe"}}
+  ],
+  "sink": {"file": ""}
+}
+`,
+			want: []string{
+				"bad.json:4: не задан обязательный параметр by",
+				`bad.json:5: параметр even: ожидается true или false, получено "maybe"`,
+				"bad.json:7: sink.file: ожидается путь к файлу",
+			},
+		},
+		{
+			file: "dup.yml",
+			src:  "source:\n  numbers: [1]\nsource:\n  numbers: [2]\n",
+			want: []string{`dup.yml:3: ключ "source" указан повторно`},
+		},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.file)
+		if err := os.WriteFile(path, []byte(c.src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(path)
+		if err == nil {
+			t.Errorf("%s: ожидались ошибки", c.file)
+			continue
+		}
+		got := strings.Split(err.Error(), "\n")
+		if len(got) != len(c.want) {
+			t.Errorf("%s: получено %d ошибок, ожидалось %d:\n%v", c.file, len(got), len(c.want), err)
+			continue
+		}
+		for i, want := range c.want {
+			if !strings.HasPrefix(got[i], filepath.Join(dir, want)) {
+				t.Errorf("%s: ошибка %d = %q, ожидалось начало %q", c.file, i+1, got[i], want)
+			}
+		}
+	}
+}
+
+func TestLoadValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ok.yaml")
+	src := "mode: bigint\nsource:\n  numbers: [1, 2, 3]\nstages:\n  - multiply: {factor: 2}\n    workers: 2\n    ordered: true\n  - filter: {min: 3}\nsink: stdout\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Stages) != 2 || s.Stages[0].Line != 5 || s.Stages[1].Line != 8 || s.Stages[0].Workers != 2 || !s.Stages[0].Ordered {
+		t.Fatalf("этапы разобраны неверно: %+v", s.Stages)
+	}
+}
@@ -0,0 +1,340 @@
+package spec
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseYAML разбирает подмножество YAML, достаточное для описания конвейера:
+// вложенные объекты и списки на отступах, однострочные {ключ: значение} и [a, b],
+// строки в кавычках и без, комментарии #. якоря, многострочные строки и несколько документов не поддерживаются
+func ParseYAML(data []byte) (*Node, error) {
+	lines, err := yamlLines(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, &Error{Msg: "пустая конфигурация"}
+	}
+	p := &yamlParser{lines: lines}
+	root, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(lines) {
+		return nil, &Error{Line: lines[p.pos].no, Msg: "неожиданный отступ"}
+	}
+	return root, nil
+}
+
+// yamlLine - значимая строка YAML: номер, отступ и текст без отступа и комментария
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+// yamlLines отбрасывает пустые строки и комментарии и вычисляет отступы
+func yamlLines(src string) ([]yamlLine, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(src, "\n") {
+		no := i + 1
+		raw = strings.TrimRight(stripComment(raw), " \t\r")
+		text := strings.TrimLeft(raw, " ")
+		if text == "" || text == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return nil, &Error{Line: no, Msg: "табуляция в отступе не допускается"}
+		}
+		lines = append(lines, yamlLine{no: no, indent: len(raw) - len(text), text: text})
+	}
+	return lines, nil
+}
+
+// stripComment убирает комментарий "# ...", не трогая # внутри кавычек
+func stripComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+func isListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// block разбирает объект или список, строки которого начинаются с отступа indent
+func (p *yamlParser) block(indent int) (*Node, error) {
+	if isListItem(p.lines[p.pos].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (*Node, error) {
+	n := &Node{Kind: Sequence, Line: p.lines[p.pos].no}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isListItem(p.lines[p.pos].text) {
+		l := p.lines[p.pos]
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		var (
+			item *Node
+			err  error
+		)
+		switch {
+		case rest == "": // элемент - вложенный блок на следующих строках
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				item, err = p.block(p.lines[p.pos].indent)
+			} else {
+				item = &Node{Kind: Scalar, Line: l.no}
+			}
+		case hasKey(rest): // "- ключ: значение" - объект, продолжающийся на строках с тем же отступом, что и ключ
+			p.lines[p.pos] = yamlLine{no: l.no, indent: indent + len(l.text) - len(rest), text: rest}
+			item, err = p.mapping(p.lines[p.pos].indent)
+		default:
+			item, err = parseFlow(rest, l.no)
+			p.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		n.Items = append(n.Items, item)
+		if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+			return nil, &Error{Line: p.lines[p.pos].no, Msg: "неожиданный отступ"}
+		}
+	}
+	return n, nil
+}
+
+func (p *yamlParser) mapping(indent int) (*Node, error) {
+	n := newMapping(p.lines[p.pos].no)
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && !isListItem(p.lines[p.pos].text) {
+		l := p.lines[p.pos]
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			return nil, &Error{Line: l.no, Msg: fmt.Sprintf("ожидается \"ключ: значение\", получено %q", l.text)}
+		}
+		p.pos++
+
+		var (
+			v   *Node
+			err error
+		)
+		switch {
+		case rest != "":
+			v, err = parseFlow(rest, l.no)
+		case p.pos < len(p.lines) && p.lines[p.pos].indent > indent:
+			v, err = p.block(p.lines[p.pos].indent)
+		case p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isListItem(p.lines[p.pos].text):
+			v, err = p.sequence(indent) // список может начинаться на том же отступе, что и ключ
+		default:
+			v = &Node{Kind: Scalar, Line: l.no} // "ключ:" без значения - пустое значение
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := n.set(key, v, l.no); err != nil {
+			return nil, err
+		}
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, &Error{Line: p.lines[p.pos].no, Msg: "неожиданный отступ"}
+	}
+	return n, nil
+}
+
+// hasKey сообщает, начинается ли текст с "ключ:" (а не, например, с однострочного объекта)
+func hasKey(text string) bool {
+	if text[0] == '{' || text[0] == '[' {
+		return false
+	}
+	_, _, ok := splitKey(text)
+	return ok
+}
+
+// splitKey делит "ключ: значение" на части; двоеточие внутри кавычек не считается
+func splitKey(text string) (key, rest string, ok bool) {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i == len(text)-1 || text[i+1] == ' ' || text[i+1] == '\t'): // после двоеточия - пробел или табуляция
+			key = strings.TrimSpace(text[:i])
+			if key == "" {
+				return "", "", false
+			}
+			return unquote(key), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// unquote снимает кавычки со строки, если они есть
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"') {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+// parseFlow разбирает однострочное значение: скаляр, {ключ: значение, ...} или [a, b, ...]
+func parseFlow(s string, line int) (*Node, error) {
+	f := &flowParser{s: s, line: line}
+	n, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpace()
+	if f.pos < len(f.s) {
+		return nil, f.errorf("лишние символы %q", f.s[f.pos:])
+	}
+	return n, nil
+}
+
+type flowParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (f *flowParser) errorf(format string, args ...any) error {
+	return &Error{Line: f.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (f *flowParser) skipSpace() {
+	for f.pos < len(f.s) && (f.s[f.pos] == ' ' || f.s[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *flowParser) value() (*Node, error) {
+	f.skipSpace()
+	if f.pos >= len(f.s) {
+		return &Node{Kind: Scalar, Line: f.line}, nil
+	}
+	switch f.s[f.pos] {
+	case '{':
+		return f.mapping()
+	case '[':
+		return f.sequence()
+	}
+	return &Node{Kind: Scalar, Line: f.line, Value: f.scalar(",]}")}, nil
+}
+
+// scalar читает скаляр до одного из символов stop (вне кавычек)
+func (f *flowParser) scalar(stop string) string {
+	start := f.pos
+	var quote byte
+	for ; f.pos < len(f.s); f.pos++ {
+		c := f.s[f.pos]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '"' || c == '\'' {
+			quote = c
+			continue
+		}
+		if strings.IndexByte(stop, c) >= 0 {
+			break
+		}
+	}
+	v := strings.TrimSpace(f.s[start:f.pos])
+	if v == "~" || v == "null" {
+		return ""
+	}
+	return unquote(v)
+}
+
+func (f *flowParser) mapping() (*Node, error) {
+	n := newMapping(f.line)
+	f.pos++ // '{'
+	for {
+		f.skipSpace()
+		if f.pos < len(f.s) && f.s[f.pos] == '}' {
+			f.pos++
+			return n, nil
+		}
+		key := f.scalar(":,}")
+		if f.pos >= len(f.s) || f.s[f.pos] != ':' || key == "" {
+			return nil, f.errorf("ожидается \"ключ: значение\" в {...}")
+		}
+		f.pos++ // ':'
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := n.set(key, v, f.line); err != nil {
+			return nil, err
+		}
+		if err := f.next('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *flowParser) sequence() (*Node, error) {
+	n := &Node{Kind: Sequence, Line: f.line}
+	f.pos++ // '['
+	for {
+		f.skipSpace()
+		if f.pos < len(f.s) && f.s[f.pos] == ']' {
+			f.pos++
+			return n, nil
+		}
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		n.Items = append(n.Items, v)
+		if err := f.next(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next пропускает запятую между элементами; закрывающую скобку close оставляет вызывающему
+func (f *flowParser) next(close byte) error {
+	f.skipSpace()
+	switch {
+	case f.pos >= len(f.s):
+		return f.errorf("не хватает %q", close)
+	case f.s[f.pos] == ',':
+		f.pos++
+		return nil
+	case f.s[f.pos] == close:
+		return nil
+	}
+	return f.errorf("ожидается ',' или %q, получено %q", close, f.s[f.pos:])
+}
@@ -0,0 +1,130 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump записывает дерево узлов компактно и с номерами строк: value@line, [..]@line, {k: v}@line
+func dump(n *Node) string {
+	switch n.Kind {
+	case Mapping:
+		parts := make([]string, len(n.Keys))
+		for i, k := range n.Keys {
+			parts[i] = k + ": " + dump(n.Fields[k])
+		}
+		return fmt.Sprintf("{%s}@%d", strings.Join(parts, ", "), n.Line)
+	case Sequence:
+		parts := make([]string, len(n.Items))
+		for i, item := range n.Items {
+			parts[i] = dump(item)
+		}
+		return fmt.Sprintf("[%s]@%d", strings.Join(parts, ", "), n.Line)
+	}
+	return fmt.Sprintf("%q@%d", n.Value, n.Line)
+}
+
+func TestParseYAML(t *testing.T) {
+	cases := []struct {
+		name, src, want string
+	}{
+		{
+			name: "вложенные блоки и однострочные значения",
+			src:  "a:\n  b:\n    c: 1\n  d: [x, \"y z\"]\ne: {f: 2, g: [3]}\n",
+			want: `{a: {b: {c: "1"@3}@3, d: ["x"@4, "y z"@4]@4}@2, e: {f: "2"@5, g: ["3"@5]@5}@5}@1`,
+		},
+		{
+			name: "элементы - ключ: и продолжение объекта",
+			src:  "stages:\n  - multiply: {factor: 2}\n    workers: 3\n  - filter:\n      even: true\n  - repeat\nsink: stdout\n",
+			want: `{stages: [{multiply: {factor: "2"@2}@2, workers: "3"@3}@2, {filter: {even: "true"@5}@5}@4, "repeat"@6]@2, sink: "stdout"@7}@1`,
+		},
+		{
+			name: "комментарии, кавычки и список на отступе ключа",
+			src:  "# заголовок\nname: \"a # b\" # комментарий\nsingle: 'it''s # not'\n\nlist:\n- 1\n-\n  - 2\n",
+			want: `{name: "a # b"@2, single: "it's # not"@3, list: ["1"@6, ["2"@8]@8]@6}@2`,
+		},
+		{
+			name: "пустые значения и null",
+			src:  "---\na:\nb: ~\nc: {d: null, e: []}\n",
+			want: `{a: ""@2, b: ""@3, c: {d: ""@4, e: []@4}@4}@2`,
+		},
+		{
+			name: "табуляция внутри значения допустима",
+			src:  "a: \"x\ty\"\nb:\t1\nc: {d:\t2,\te: 3}\n",
+			want: "{a: \"x\\ty\"@1, b: \"1\"@2, c: {d: \"2\"@3, e: \"3\"@3}@3}@1",
+		},
+	}
+	for _, c := range cases {
+		n, err := ParseYAML([]byte(c.src))
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got := dump(n); got != c.want {
+			t.Errorf("%s:\nполучено  %s\nожидалось %s", c.name, got, c.want)
+		}
+	}
+}
+
+// ошибки разбора указывают строку, в которой они найдены
+func TestParseYAMLErrors(t *testing.T) {
+	cases := []struct {
+		name, src string
+		line      int
+		msg       string
+	}{
+		{"табуляция в отступе", "a:\n\tb: 1\n", 2, "табуляция"},
+		{"табуляция после пробелов отступа", "a:\n  \tb: 1\n", 2, "табуляция"},
+		{"повторный ключ", "a: 1\nb: 2\n# c\na: 3\n", 4, `ключ "a" указан повторно`},
+		{"повторный ключ во вложенном блоке", "a:\n  b: 1\n  b: 2\n", 3, `ключ "b" указан повторно`},
+		{"повторный ключ в {...}", "x: 1\na: {b: 1, b: 2}\n", 2, `ключ "b" указан повторно`},
+		{"повторный ключ в элементе списка", "l:\n  - a: 1\n    a: 2\n", 3, `ключ "a" указан повторно`},
+		{"неожиданный отступ", "a: 1\n    b: 2\n", 2, "неожиданный отступ"},
+		{"отступ после элемента списка", "l:\n  - 1\n     - 2\n", 3, "неожиданный отступ"},
+		{"не ключ: значение", "a: 1\njust text\n", 2, "ожидается"},
+		{"незакрытый список", "a: 1\nb: [1, 2\n", 2, `не хватает ']'`},
+		{"незакрытый объект", "a: {b: 1\n", 1, `не хватает '}'`},
+		{"лишнее после скобки", "a: [1] x\n", 1, "лишние символы"},
+	}
+	for _, c := range cases {
+		_, err := ParseYAML([]byte(c.src))
+		ce, ok := err.(*Error)
+		if !ok {
+			t.Errorf("%s: ожидалась *Error, получено %v", c.name, err)
+			continue
+		}
+		if ce.Line != c.line || !strings.Contains(ce.Msg, c.msg) {
+			t.Errorf("%s: получено %q (строка %d), ожидалась строка %d и %q", c.name, ce.Msg, ce.Line, c.line, c.msg)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	src := "{\n  \"a\": {\"b\": 1},\n  \"l\": [\n    \"x\",\n    2.5,\n    null\n  ]\n}\n"
+	n, err := ParseJSON([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{a: {b: "1"@2}@2, l: ["x"@4, "2.5"@5, ""@6]@3}@1`
+	if got := dump(n); got != want {
+		t.Fatalf("получено  %s\nожидалось %s", got, want)
+	}
+
+	errCases := []struct {
+		src  string
+		line int
+		msg  string
+	}{
+		{"{\n  \"a\": 1,\n  \"a\": 2\n}", 3, `ключ "a" указан повторно`},
+		{"{\n  \"a\": [1,\n    2\n", 4, "некорректный JSON"},
+		{"{\"a\": 1}\n\n{}", 3, "лишние данные"},
+	}
+	for _, c := range errCases {
+		_, err := ParseJSON([]byte(c.src))
+		ce, ok := err.(*Error)
+		if !ok || ce.Line != c.line || !strings.Contains(ce.Msg, c.msg) {
+			t.Errorf("ParseJSON(%q): получено %v, ожидалась строка %d и %q", c.src, err, c.line, c.msg)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/Kras0Tanya/WB-L1
+
+go 1.22
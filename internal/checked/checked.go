@@ -0,0 +1,132 @@
+// Package checked - целочисленная арифметика с проверкой переполнения для всех целых типов Go.
+// обычные операторы при переполнении молча "заворачивают" результат (MaxInt64 + 1 == MinInt64);
+// функции пакета вместо этого возвращают ErrOverflow, а варианты Sat* - ближайшую границу типа
+package checked
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// ErrOverflow - точный результат не помещается в тип
+var ErrOverflow = errors.New("checked: переполнение")
+
+// Integer - все целые типы, включая определённые на их основе
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Limits возвращает наименьшее и наибольшее значения типа T
+func Limits[T Integer]() (lo, hi T) {
+	var zero T
+	if ^zero > 0 { // беззнаковый тип: все единицы - максимум
+		return 0, ^zero
+	}
+	// знаковый: только старший бит - минимум, все остальные биты - максимум
+	lo = T(1) << (8*unsafe.Sizeof(zero) - 1)
+	return lo, ^lo
+}
+
+// Add возвращает a + b или ErrOverflow
+func Add[T Integer](a, b T) (T, error) {
+	c := a + b
+	// для беззнаковых b >= 0, и переполнение - когда сумма стала меньше a
+	if (b > 0 && c < a) || (b < 0 && c > a) {
+		return 0, ErrOverflow
+	}
+	return c, nil
+}
+
+// Sub возвращает a - b или ErrOverflow
+func Sub[T Integer](a, b T) (T, error) {
+	c := a - b
+	if (b > 0 && c > a) || (b < 0 && c < a) {
+		return 0, ErrOverflow
+	}
+	return c, nil
+}
+
+// Mul возвращает a * b или ErrOverflow
+func Mul[T Integer](a, b T) (T, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	c := a * b
+	// деление обратно ловит все переполнения, кроме MinInt * -1: там и c, и c / -1 снова равны MinInt
+	var zero T
+	lo, _ := Limits[T]()
+	if minusOne := ^zero; lo < 0 && ((a == minusOne && b == lo) || (b == minusOne && a == lo)) {
+		return 0, ErrOverflow
+	}
+	if c/b != a {
+		return 0, ErrOverflow
+	}
+	return c, nil
+}
+
+// Pow возвращает base в степени exp (возведение в квадрат с умножением) или ErrOverflow
+func Pow[T Integer](base T, exp uint) (T, error) {
+	result := T(1)
+	for {
+		if exp&1 == 1 {
+			var err error
+			if result, err = Mul(result, base); err != nil {
+				return 0, err
+			}
+		}
+		exp >>= 1
+		if exp == 0 {
+			return result, nil
+		}
+		var err error
+		if base, err = Mul(base, base); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// SatAdd возвращает a + b, а при переполнении - ближайшую к точному результату границу типа
+func SatAdd[T Integer](a, b T) T {
+	c, err := Add(a, b)
+	if err != nil {
+		return bound[T](b > 0)
+	}
+	return c
+}
+
+// SatSub возвращает a - b, а при переполнении - ближайшую к точному результату границу типа
+func SatSub[T Integer](a, b T) T {
+	c, err := Sub(a, b)
+	if err != nil {
+		return bound[T](b < 0)
+	}
+	return c
+}
+
+// SatMul возвращает a * b, а при переполнении - ближайшую к точному результату границу типа
+func SatMul[T Integer](a, b T) T {
+	c, err := Mul(a, b)
+	if err != nil {
+		return bound[T]((a < 0) == (b < 0))
+	}
+	return c
+}
+
+// SatPow возвращает base в степени exp, а при переполнении - ближайшую к точному результату границу типа
+func SatPow[T Integer](base T, exp uint) T {
+	c, err := Pow(base, exp)
+	if err != nil {
+		return bound[T](base > 0 || exp%2 == 0)
+	}
+	return c
+}
+
+// bound возвращает максимум типа для положительного точного результата и минимум - для отрицательного
+func bound[T Integer](positive bool) T {
+	lo, hi := Limits[T]()
+	if positive {
+		return hi
+	}
+	return lo
+}
@@ -0,0 +1,162 @@
+package checked
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// result - значение и ошибка одной операции
+type result[T Integer] struct {
+	v   T
+	err error
+}
+
+// r собирает результат checked-операции в одно значение, чтобы его можно было положить в таблицу
+func r[T Integer](v T, err error) result[T] { return result[T]{v, err} }
+
+// opCase - результат операции и ожидание
+type opCase[T Integer] struct {
+	name      string
+	got, want result[T]
+}
+
+func runCases[T Integer](t *testing.T, cases []opCase[T]) {
+	t.Helper()
+	for _, c := range cases {
+		if c.got.v != c.want.v || !errors.Is(c.got.err, c.want.err) || (c.got.err == nil) != (c.want.err == nil) {
+			t.Errorf("%s: получено %d, %v; ожидалось %d, %v", c.name, c.got.v, c.got.err, c.want.v, c.want.err)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	if lo, hi := Limits[int8](); lo != math.MinInt8 || hi != math.MaxInt8 {
+		t.Errorf("int8: %d..%d", lo, hi)
+	}
+	if lo, hi := Limits[uint8](); lo != 0 || hi != math.MaxUint8 {
+		t.Errorf("uint8: %d..%d", lo, hi)
+	}
+	if lo, hi := Limits[int64](); lo != math.MinInt64 || hi != math.MaxInt64 {
+		t.Errorf("int64: %d..%d", lo, hi)
+	}
+	if lo, hi := Limits[uint64](); lo != 0 || hi != math.MaxUint64 {
+		t.Errorf("uint64: %d..%d", lo, hi)
+	}
+	type myInt16 int16 // определённый тип проверяется по базовому
+	if lo, hi := Limits[myInt16](); lo != math.MinInt16 || hi != math.MaxInt16 {
+		t.Errorf("myInt16: %d..%d", lo, hi)
+	}
+}
+
+func TestInt64(t *testing.T) {
+	const maxSqrt = 3037000499 // наибольшее число, квадрат которого помещается в int64
+	ok := func(v int64) result[int64] { return result[int64]{v: v} }
+	ovf := result[int64]{err: ErrOverflow}
+	runCases(t, []opCase[int64]{
+		{"MaxInt64 + 0", r(Add[int64](math.MaxInt64, 0)), ok(math.MaxInt64)},
+		{"MaxInt64 + 1", r(Add[int64](math.MaxInt64, 1)), ovf},
+		{"MinInt64 + -1", r(Add[int64](math.MinInt64, -1)), ovf},
+		{"MinInt64 + MaxInt64", r(Add[int64](math.MinInt64, math.MaxInt64)), ok(-1)},
+		{"MinInt64 - 1", r(Sub[int64](math.MinInt64, 1)), ovf},
+		{"0 - MinInt64", r(Sub[int64](0, math.MinInt64)), ovf},
+		{"-1 - MaxInt64", r(Sub[int64](-1, math.MaxInt64)), ok(math.MinInt64)},
+		{"MinInt64 * -1", r(Mul[int64](math.MinInt64, -1)), ovf},
+		{"-1 * MinInt64", r(Mul[int64](-1, math.MinInt64)), ovf},
+		{"MinInt64 * 1", r(Mul[int64](math.MinInt64, 1)), ok(math.MinInt64)},
+		{"MaxInt64 * -1", r(Mul[int64](math.MaxInt64, -1)), ok(-math.MaxInt64)},
+		{"3037000499²", r(Mul[int64](maxSqrt, maxSqrt)), ok(maxSqrt * maxSqrt)},
+		{"3037000500²", r(Mul[int64](maxSqrt+1, maxSqrt+1)), ovf},
+		{"-3037000500²", r(Mul[int64](-maxSqrt-1, -maxSqrt-1)), ovf},
+		{"2^62", r(Pow[int64](2, 62)), ok(1 << 62)},
+		{"2^63", r(Pow[int64](2, 63)), ovf},
+		{"(-2)^63", r(Pow[int64](-2, 63)), ok(math.MinInt64)},
+		{"(-2)^64", r(Pow[int64](-2, 64)), ovf},
+		{"MinInt64^0", r(Pow[int64](math.MinInt64, 0)), ok(1)},
+		{"sat MaxInt64 + 1", ok(SatAdd[int64](math.MaxInt64, 1)), ok(math.MaxInt64)},
+		{"sat MinInt64 - 1", ok(SatSub[int64](math.MinInt64, 1)), ok(math.MinInt64)},
+		{"sat MinInt64 * -1", ok(SatMul[int64](math.MinInt64, -1)), ok(math.MaxInt64)},
+		{"sat MaxInt64 * -2", ok(SatMul[int64](math.MaxInt64, -2)), ok(math.MinInt64)},
+		{"sat (-2)^65", ok(SatPow[int64](-2, 65)), ok(math.MinInt64)},
+		{"sat (-2)^64", ok(SatPow[int64](-2, 64)), ok(math.MaxInt64)},
+	})
+}
+
+// на int8 все граничные случаи знаковой ветки Limits и Mul видны на маленьких числах
+func TestInt8(t *testing.T) {
+	ok := func(v int8) result[int8] { return result[int8]{v: v} }
+	ovf := result[int8]{err: ErrOverflow}
+	runCases(t, []opCase[int8]{
+		{"127 + 1", r(Add[int8](127, 1)), ovf},
+		{"-128 + -1", r(Add[int8](-128, -1)), ovf},
+		{"-128 + 127", r(Add[int8](-128, 127)), ok(-1)},
+		{"-128 - 1", r(Sub[int8](-128, 1)), ovf},
+		{"0 - -128", r(Sub[int8](0, -128)), ovf},
+		{"-1 - 127", r(Sub[int8](-1, 127)), ok(-128)},
+		{"-128 * -1", r(Mul[int8](-128, -1)), ovf},
+		{"-1 * -128", r(Mul[int8](-1, -128)), ovf},
+		{"-128 * 1", r(Mul[int8](-128, 1)), ok(-128)},
+		{"-64 * 2", r(Mul[int8](-64, 2)), ok(-128)},
+		{"64 * 2", r(Mul[int8](64, 2)), ovf},
+		{"11 * 11", r(Mul[int8](11, 11)), ok(121)},
+		{"12 * 12", r(Mul[int8](12, 12)), ovf},
+		{"-16 * -8", r(Mul[int8](-16, -8)), ovf},
+		{"2^6", r(Pow[int8](2, 6)), ok(64)},
+		{"2^7", r(Pow[int8](2, 7)), ovf},
+		{"(-2)^7", r(Pow[int8](-2, 7)), ok(-128)},
+		{"3^5", r(Pow[int8](3, 5)), ovf},
+		{"sat 100 + 100", ok(SatAdd[int8](100, 100)), ok(127)},
+		{"sat -100 + -100", ok(SatAdd[int8](-100, -100)), ok(-128)},
+		{"sat 100 - -100", ok(SatSub[int8](100, -100)), ok(127)},
+		{"sat -100 - 100", ok(SatSub[int8](-100, 100)), ok(-128)},
+		{"sat -128 * -1", ok(SatMul[int8](-128, -1)), ok(127)},
+		{"sat 100 * -2", ok(SatMul[int8](100, -2)), ok(-128)},
+		{"sat (-3)^5", ok(SatPow[int8](-3, 5)), ok(-128)},
+		{"sat (-3)^6", ok(SatPow[int8](-3, 6)), ok(127)},
+	})
+}
+
+// беззнаковые типы: переполнение вниз - это уход ниже нуля, ^zero - максимум, а не -1
+func TestUint8(t *testing.T) {
+	ok := func(v uint8) result[uint8] { return result[uint8]{v: v} }
+	ovf := result[uint8]{err: ErrOverflow}
+	runCases(t, []opCase[uint8]{
+		{"255 + 0", r(Add[uint8](255, 0)), ok(255)},
+		{"255 + 1", r(Add[uint8](255, 1)), ovf},
+		{"200 + 100", r(Add[uint8](200, 100)), ovf},
+		{"0 - 1", r(Sub[uint8](0, 1)), ovf},
+		{"5 - 5", r(Sub[uint8](5, 5)), ok(0)},
+		{"255 * 1", r(Mul[uint8](255, 1)), ok(255)},
+		{"1 * 255", r(Mul[uint8](1, 255)), ok(255)},
+		{"255 * 255", r(Mul[uint8](255, 255)), ovf},
+		{"15 * 17", r(Mul[uint8](15, 17)), ok(255)},
+		{"16 * 16", r(Mul[uint8](16, 16)), ovf},
+		{"2^7", r(Pow[uint8](2, 7)), ok(128)},
+		{"2^8", r(Pow[uint8](2, 8)), ovf},
+		{"sat 200 + 100", ok(SatAdd[uint8](200, 100)), ok(255)},
+		{"sat 1 - 2", ok(SatSub[uint8](1, 2)), ok(0)},
+		{"sat 16 * 16", ok(SatMul[uint8](16, 16)), ok(255)},
+		{"sat 3^6", ok(SatPow[uint8](3, 6)), ok(255)},
+	})
+}
+
+func TestUint64(t *testing.T) {
+	const maxSqrt = 1<<32 - 1 // наибольшее число, квадрат которого помещается в uint64
+	ok := func(v uint64) result[uint64] { return result[uint64]{v: v} }
+	ovf := result[uint64]{err: ErrOverflow}
+	runCases(t, []opCase[uint64]{
+		{"MaxUint64 + 1", r(Add[uint64](math.MaxUint64, 1)), ovf},
+		{"MaxInt64 + MaxInt64 + 1", r(Add[uint64](math.MaxInt64, math.MaxInt64+1)), ok(math.MaxUint64)},
+		{"0 - 1", r(Sub[uint64](0, 1)), ovf},
+		{"MaxUint64 * 1", r(Mul[uint64](math.MaxUint64, 1)), ok(math.MaxUint64)},
+		{"MaxUint64 * 2", r(Mul[uint64](math.MaxUint64, 2)), ovf},
+		{"(2^32-1)²", r(Mul[uint64](maxSqrt, maxSqrt)), ok(maxSqrt * maxSqrt)},
+		{"(2^32)²", r(Mul[uint64](maxSqrt+1, maxSqrt+1)), ovf},
+		{"2^63", r(Pow[uint64](2, 63)), ok(1 << 63)},
+		{"2^64", r(Pow[uint64](2, 64)), ovf},
+		{"sat MaxUint64 + 1", ok(SatAdd[uint64](math.MaxUint64, 1)), ok(math.MaxUint64)},
+		{"sat 0 - 1", ok(SatSub[uint64](0, 1)), ok(0)},
+		{"sat MaxUint64 * 2", ok(SatMul[uint64](math.MaxUint64, 2)), ok(math.MaxUint64)},
+		{"sat 2^64", ok(SatPow[uint64](2, 64)), ok(math.MaxUint64)},
+	})
+}
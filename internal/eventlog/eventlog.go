@@ -0,0 +1,123 @@
+// Package eventlog - общий для программ с воркерами (L1.3, L1.4, L1.5) вывод событий через log/slog.
+// каждое событие - структурированная запись со временем, именем события (ключ event) и полями вроде worker, item, duration;
+// формат выбирается флагом --format: text (для человека), json или logfmt (для разбора в пайплайне логов)
+package eventlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// имена событий (значение ключа event)
+const (
+	EventWorkerStart   = "worker_start"
+	EventWorkerStop    = "worker_stop"
+	EventItemProcessed = "item_processed"
+	EventItemFailed    = "item_failed"
+	EventResize        = "resize"
+	EventRateChange    = "rate_change"
+	EventShutdown      = "shutdown"
+	EventTimeout       = "timeout"
+	EventSummary       = "summary"
+)
+
+// ключи полей записей
+const (
+	KeyEvent    = "event"
+	KeyWorker   = "worker"
+	KeyItem     = "item"
+	KeyDuration = "duration"
+)
+
+// Formats - поддерживаемые значения флага --format
+var Formats = []string{"text", "json", "logfmt"}
+
+// FormatUsage - готовое описание для flag.String("format", "text", eventlog.FormatUsage)
+var FormatUsage = "формат вывода событий: " + strings.Join(Formats, ", ")
+
+// New создаёт логгер, пишущий в w в указанном формате
+func New(w io.Writer, format string) (*slog.Logger, error) {
+	switch format {
+	case "text", "":
+		return slog.New(&textHandler{mu: &sync.Mutex{}, w: w}), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, nil)), nil
+	case "logfmt":
+		return slog.New(slog.NewTextHandler(w, nil)), nil // TextHandler пишет key=value в формате logfmt
+	default:
+		return nil, fmt.Errorf("eventlog: неизвестный формат %q (допустимо: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// textHandler - человекочитаемый формат: "15:04:05.000 сообщение ключ=значение ..."
+type textHandler struct {
+	mu     *sync.Mutex // общий для всех производных обработчиков, чтобы строки не перемешивались
+	w      io.Writer
+	attrs  string // уже отформатированные атрибуты из WithAttrs
+	prefix string // префикс групп из WithGroup
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("15:04:05.000"))
+	if r.Level != slog.LevelInfo {
+		b.WriteString(" " + r.Level.String())
+	}
+	b.WriteString(" " + r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// appendAttr добавляет " ключ=значение", раскрывая группы в ключи через точку
+func (h *textHandler) appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			h.appendAttr(b, prefix, ga)
+		}
+		return
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, a.Value.String())
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		h.appendAttr(&b, h.prefix, a)
+	}
+	clone := *h
+	clone.attrs += b.String()
+	return &clone
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix += name + "."
+	return &clone
+}